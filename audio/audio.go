@@ -1,13 +1,14 @@
 package audio
 
 import (
-	"bufio"
+	"context"
 	"fmt"
-	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
 
+	"yt_downloader/chapters"
+	"yt_downloader/sponsorblock"
+	"yt_downloader/thumbnail"
 	"yt_downloader/utils"
 )
 
@@ -15,6 +16,18 @@ import (
 
 var AudioBitrate string = "64" // default bitrate
 
+// AudioBitrates lists the bitrates offered by PromptAudioQuality, kbps
+var AudioBitrates = []string{"32", "64", "96", "128", "256", "320", "512"}
+
+// Thumbnail controls cover art for downloaded audio
+var Thumbnail thumbnail.Options
+
+// Chapters controls chapter markers and cue sheets for downloaded audio
+var Chapters chapters.Options
+
+// SponsorBlock controls cutting or marking sponsor segments
+var SponsorBlock sponsorblock.Options
+
 func PromptAudioQuality() {
 	fmt.Println("Select audio bitrate:")
 	fmt.Println("0 - 32 kbps")
@@ -50,40 +63,77 @@ func PromptAudioQuality() {
 
 // =================== YouTube ===================
 
-func GetTitleFromURL(url string) string {
-	title := utils.GetVideoTitle(url)
+// GetTitleFromURL grabs a safe file name from the video title
+func GetTitleFromURL(ctx context.Context, url string) (string, error) {
+	title, err := utils.GetVideoTitle(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("failed to get title: %w", err)
+	}
 	if title == "" {
 		title = utils.GenerateFallbackTitle()
 	}
-	return utils.SanitizeFileName(title)
+	return utils.SanitizeFileName(title), nil
 }
 
 // =================== Audio download ===================
 
-func DownloadAudio(url, filename, folder string) {
-	ytPath := filepath.Join("bin", "yt-dlp.exe")
+// DownloadAudio downloads audio as MP3 at the selected AudioBitrate and
+// applies the post-processing options. Failed post-processing steps are
+// returned as an error matching utils.ErrPostProcessing; the MP3 is there
+// then.
+func DownloadAudio(ctx context.Context, url, filename, folder string) error {
+	return DownloadAudioWithBitrate(ctx, url, filename, folder, AudioBitrate)
+}
+
+// DownloadAudioWithBitrate is DownloadAudio at the given bitrate in kbps
+func DownloadAudioWithBitrate(ctx context.Context, url, filename, folder, bitrate string) error {
+	ytPath := utils.GetYTDLPBinary()
 	outPath := filepath.Join(folder, filename+".%(ext)s")
 
 	args := []string{
 		"-x",
 		"--audio-format", "mp3",
-		"--audio-quality", AudioBitrate + "K",
+		"--audio-quality", bitrate + "K",
 		"--ffmpeg-location", "bin",
 		"-o", outPath,
 		url,
 	}
 
-	cmd := exec.Command(ytPath, args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd := exec.CommandContext(ctx, ytPath, args...)
 
-	if err := cmd.Run(); err != nil {
-		fmt.Println("⚠ Failed to run yt-dlp:", err)
-		return
+	if err := utils.RunCommand(cmd); err != nil {
+		if utils.Interrupted(ctx, url, folder, filename) {
+			return context.Cause(ctx)
+		}
+		return fmt.Errorf("audio download error: %w", err)
 	}
 
 	fmt.Println("\n✅ Audio download and extraction completed:", filename+".mp3")
 
+	// Segments are cut first so chapters follow the cuts
+	audioPath := filepath.Join(folder, filename+".mp3")
+	// Each step finishes its file; a cancel skips the remaining ones
+	var errs []error
+	segments, err := sponsorblock.Process(ctx, url, audioPath, SponsorBlock)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("SponsorBlock error: %w", err))
+	}
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	if err := thumbnail.Process(ctx, url, audioPath, Thumbnail); err != nil {
+		errs = append(errs, fmt.Errorf("thumbnail error: %w", err))
+	}
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	if err := chapters.Process(ctx, url, audioPath, segments.ChapterOptions(Chapters), segments.EditChapters); err != nil {
+		errs = append(errs, fmt.Errorf("chapters error: %w", err))
+	}
+	if err := utils.PostProcessingError(errs...); err != nil {
+		return err
+	}
+
 	// secure call beep
 	defer func() {
 		if r := recover(); r != nil {
@@ -91,45 +141,5 @@ func DownloadAudio(url, filename, folder string) {
 		}
 	}()
 	utils.PlayBeepShort()
-}
-
-// =================== Batch download ===================
-
-func ProcessBatchFile(filePath, folder string) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		fmt.Println("⚠ Failed to open file:", filePath, "error:", err)
-		return
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		url := strings.TrimSpace(scanner.Text())
-		if url == "" {
-			continue
-		}
-
-		fmt.Println("\n🎬 Downloading:", url)
-
-		fileName := GetTitleFromURL(url)
-		if fileName == "" {
-			fmt.Println("⚠ Failed to get filename for:", url)
-			continue
-		}
-
-		//secure start every download
-		func() {
-			defer func() {
-				if r := recover(); r != nil {
-					fmt.Println("⚠ Panic during download:", r)
-				}
-			}()
-			DownloadAudio(url, fileName, folder)
-		}()
-	}
-
-	if err := scanner.Err(); err != nil {
-		fmt.Println("⚠ File read error:", err)
-	}
+	return nil
 }
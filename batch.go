@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"yt_downloader/audio"
+	"yt_downloader/library"
+	"yt_downloader/queue"
+	"yt_downloader/retry"
+	"yt_downloader/subtitles"
+	"yt_downloader/utils"
+	"yt_downloader/video"
+)
+
+// batchQueueFile keeps console batches, so an interrupted one can be resumed
+const batchQueueFile = "batch_queue.json"
+
+// Batch item modes
+const (
+	batchAudio     = "audio"
+	batchVideo     = "video"
+	batchSubtitles = "subtitles"
+)
+
+// batchItem is one URL of a batch file with the settings chosen for it
+type batchItem struct {
+	Mode      string                    `json:"mode"`
+	URL       string                    `json:"url"`
+	Folder    string                    `json:"folder"`
+	Bitrate   string                    `json:"bitrate,omitempty"`
+	Quality   *video.VideoQuality       `json:"quality,omitempty"`
+	Subtitles subtitles.SubtitleOptions `json:"subtitles"`
+	Library   library.Options           `json:"library"`
+}
+
+// runBatch downloads items through the batch queue. An unfinished batch
+// left in batch_queue.json can be resumed instead; newItems is only called
+// when starting over. Items failing for transient reasons are retried as
+// retry_policy.json says. Cancelling ctx stops the current item and leaves
+// the rest for resuming. Returns false when there was nothing to do.
+func runBatch(ctx context.Context, newItems func() []batchItem) bool {
+	total, count := 0, 0
+	q, err := queue.New(batchQueueFile, func(ctx context.Context, job queue.Job) (string, error) {
+		count++
+		var item batchItem
+		if err := job.Decode(&item); err != nil {
+			return "", err
+		}
+		fmt.Printf("\n🎬 Processing %d/%d: %s\n", count, total, item.URL)
+		file, err := runBatchItem(ctx, item)
+		if err != nil && ctx.Err() == nil {
+			printDownloadError(err)
+		}
+		return file, err
+	})
+	if err != nil {
+		fmt.Printf("⚠ %v, starting a new batch\n", err)
+	}
+	q.RemoveFinished()
+
+	policy, err := retry.LoadPolicy()
+	if err != nil {
+		fmt.Printf("⚠ %v, using the default retry policy\n", err)
+	}
+	q.Retry = policy
+
+	resume := false
+	if left := q.Unfinished(); left > 0 {
+		var choice string
+		fmt.Printf("\n⏯ The previous batch was interrupted with %d item(s) left\n", left)
+		fmt.Println("1 - Resume it (default)")
+		fmt.Println("2 - Discard it and start the new batch")
+		fmt.Print("Your choice: ")
+		fmt.Scanln(&choice)
+		resume = choice != "2"
+	}
+
+	if resume {
+		q.ResumeAll()
+	} else {
+		q.Clear()
+		q.ResumeAll()
+		items := newItems()
+		if len(items) == 0 {
+			return false
+		}
+		for _, item := range items {
+			if _, err := q.Add(item, 0); err != nil {
+				fmt.Printf("⚠ Error: %v\n", err)
+			}
+		}
+	}
+
+	total = q.Unfinished()
+	fmt.Printf("📋 Found %d items to download\n", total)
+	q.Start(ctx)
+	q.Wait()
+
+	counts := map[string]int{}
+	for _, job := range q.Jobs() {
+		counts[job.Status]++
+	}
+	if ctx.Err() != nil {
+		fmt.Printf("\n⏹ Batch stopped! Done: %d, failed: %d, cancelled: %d, left: %d\n",
+			counts[queue.StatusDone], counts[queue.StatusFailed], counts[queue.StatusCancelled], q.Unfinished())
+		q.RemoveFinished()
+		return false
+	}
+	fmt.Printf("\n🎉 Batch completed! Done: %d, failed: %d\n", counts[queue.StatusDone], counts[queue.StatusFailed])
+	q.RemoveFinished()
+	return true
+}
+
+// runBatchItem downloads one batch item and returns the produced file name
+func runBatchItem(ctx context.Context, item batchItem) (string, error) {
+	switch item.Mode {
+	case batchAudio:
+		audio.AudioBitrate = item.Bitrate
+		fileName, err := audio.GetTitleFromURL(ctx, item.URL)
+		if err != nil {
+			return "", err
+		}
+		// After failed post-processing the file is there, the item still fails
+		err = audio.DownloadAudio(ctx, item.URL, fileName, item.Folder)
+		if err != nil && !errors.Is(err, utils.ErrPostProcessing) {
+			return "", err
+		}
+		return fileName + ".mp3", err
+
+	case batchVideo:
+		if item.Quality != nil {
+			video.SelectedVideoQuality = *item.Quality
+		}
+		return downloadVideoItem(ctx, item.URL, item.Folder, item.Subtitles, item.Library)
+
+	case batchSubtitles:
+		fileName, err := video.GetVideoTitle(ctx, item.URL)
+		if err != nil {
+			return "", err
+		}
+		err = subtitles.DownloadSubtitlesOnly(ctx, item.URL, fileName, item.Folder, item.Subtitles)
+		if err != nil && !errors.Is(err, utils.ErrPostProcessing) {
+			return "", err
+		}
+		return fileName, err
+
+	default:
+		return "", fmt.Errorf("unknown batch item mode: %s", item.Mode)
+	}
+}
+
+// batchItems turns the URLs of a batch file into items with shared settings
+func batchItems(filePath string, template batchItem) func() []batchItem {
+	return func() []batchItem {
+		var items []batchItem
+		for _, url := range readBatchURLs(filePath) {
+			item := template
+			item.URL = url
+			items = append(items, item)
+		}
+		return items
+	}
+}
+
+// processAudioBatchFile downloads audio for every URL in a file
+func processAudioBatchFile(ctx context.Context, filePath string, folder string) {
+	item := batchItem{Mode: batchAudio, Folder: folder, Bitrate: audio.AudioBitrate}
+	if runBatch(ctx, batchItems(filePath, item)) {
+		utils.PlayBeepLong()
+	}
+}
@@ -0,0 +1,254 @@
+package chapters
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"yt_downloader/metadata"
+)
+
+// Export formats for Options.Export
+const (
+	FormatText       = "txt"    // "00:00 Title" lines, as in YouTube descriptions
+	FormatFFMetadata = "ffmeta" // ffmpeg FFMETADATA1
+	FormatCue        = "cue"    // cue sheet for single-file audio
+)
+
+// Chapter is a named section of a media file
+type Chapter struct {
+	Start time.Duration
+	End   time.Duration // 0 = until the end of the media
+	Title string
+}
+
+// Options controls chapter handling after a download
+type Options struct {
+	Embed  bool     // write chapters into the container
+	Export []string // txt, ffmeta, cue
+}
+
+// Enabled reports whether chapters are needed at all
+func (o Options) Enabled() bool {
+	return o.Embed || len(o.Export) > 0
+}
+
+// FromMetadata returns the video's chapters, or chapters parsed from the
+// description timestamps when the video has none
+func FromMetadata(video *metadata.Video) []Chapter {
+	var chapters []Chapter
+	for _, ch := range video.Chapters {
+		chapters = append(chapters, Chapter{
+			Start: seconds(ch.StartTime),
+			End:   seconds(ch.EndTime),
+			Title: strings.TrimSpace(ch.Title),
+		})
+	}
+	if len(chapters) > 0 {
+		return chapters
+	}
+	return FromDescription(video.Description, video.Length())
+}
+
+// Description lines with a timestamp: "0:00 Intro", "1. 00:01:30 - Part",
+// "[12:00] Q&A" and "Intro - 0:00"
+var (
+	leadingTimeRe  = regexp.MustCompile(`^(?:\d+[.)]\s*)?[\[(]?((?:\d{1,2}:)?\d{1,2}:\d{2})[\])]?\s*[-–—:|.]?\s*(.+)$`)
+	trailingTimeRe = regexp.MustCompile(`^(.+?)\s*[-–—:|]?\s*[\[(]?((?:\d{1,2}:)?\d{1,2}:\d{2})[\])]?$`)
+)
+
+// FromDescription derives chapters from timestamps in a description using
+// YouTube's own rules: the first one is 0:00, there are at least three and
+// they go up. Anything else is not treated as a chapter list. With an
+// unknown duration the last chapter is left open.
+func FromDescription(description string, duration time.Duration) []Chapter {
+	var chapters []Chapter
+	for _, line := range strings.Split(description, "\n") {
+		line = strings.TrimSpace(line)
+
+		var clock, title string
+		if m := leadingTimeRe.FindStringSubmatch(line); m != nil {
+			clock, title = m[1], m[2]
+		} else if m := trailingTimeRe.FindStringSubmatch(line); m != nil {
+			clock, title = m[2], m[1]
+		} else {
+			continue
+		}
+
+		start, err := parseClock(clock)
+		if err != nil {
+			continue
+		}
+		if len(chapters) > 0 && start <= chapters[len(chapters)-1].Start {
+			continue
+		}
+		if duration > 0 && start >= duration {
+			continue
+		}
+		chapters = append(chapters, Chapter{Start: start, Title: strings.TrimSpace(title)})
+	}
+
+	if len(chapters) < 3 || chapters[0].Start != 0 {
+		return nil
+	}
+
+	for i := range chapters {
+		if i+1 < len(chapters) {
+			chapters[i].End = chapters[i+1].Start
+		} else {
+			chapters[i].End = duration
+		}
+	}
+	return chapters
+}
+
+// open reports whether any chapter runs until the end of the media
+func open(chapters []Chapter) bool {
+	for _, ch := range chapters {
+		if ch.End == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// closeOpen ends open chapters at the media duration
+func closeOpen(chapters []Chapter, duration time.Duration) []Chapter {
+	closed := make([]Chapter, 0, len(chapters))
+	for _, ch := range chapters {
+		if ch.End == 0 {
+			ch.End = duration
+		}
+		if ch.End > ch.Start {
+			closed = append(closed, ch)
+		}
+	}
+	return closed
+}
+
+// parseClock parses "M:SS" or "H:MM:SS"
+func parseClock(s string) (time.Duration, error) {
+	var total time.Duration
+	for _, part := range strings.Split(s, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, err
+		}
+		total = total*60 + time.Duration(n)
+	}
+	return total * time.Second, nil
+}
+
+// Text renders chapters as "00:00 Title" lines
+func Text(chapters []Chapter) string {
+	long := len(chapters) > 0 && chapters[len(chapters)-1].Start >= time.Hour
+
+	var b strings.Builder
+	for _, ch := range chapters {
+		fmt.Fprintf(&b, "%s %s\n", formatClock(ch.Start, long), ch.Title)
+	}
+	return b.String()
+}
+
+// FFMetadata renders chapters as an ffmpeg FFMETADATA1 file
+func FFMetadata(chapters []Chapter) string {
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+	for _, ch := range chapters {
+		b.WriteString("\n[CHAPTER]\nTIMEBASE=1/1000\n")
+		fmt.Fprintf(&b, "START=%d\n", ch.Start.Milliseconds())
+		fmt.Fprintf(&b, "END=%d\n", ch.End.Milliseconds())
+		fmt.Fprintf(&b, "title=%s\n", escapeFFMetadata(ch.Title))
+	}
+	return b.String()
+}
+
+// Cue renders a cue sheet with one track per chapter for audioFile
+func Cue(chapters []Chapter, audioFile, performer, title string) string {
+	// Players ignore the type for compressed audio, MP3 is the usual choice
+	fileType := "MP3"
+	if strings.EqualFold(filepath.Ext(audioFile), ".wav") {
+		fileType = "WAVE"
+	}
+
+	var b strings.Builder
+	if performer != "" {
+		fmt.Fprintf(&b, "PERFORMER %s\n", quoteCue(performer))
+	}
+	if title != "" {
+		fmt.Fprintf(&b, "TITLE %s\n", quoteCue(title))
+	}
+	fmt.Fprintf(&b, "FILE %s %s\n", quoteCue(filepath.Base(audioFile)), fileType)
+	for i, ch := range chapters {
+		fmt.Fprintf(&b, "  TRACK %02d AUDIO\n", i+1)
+		fmt.Fprintf(&b, "    TITLE %s\n", quoteCue(ch.Title))
+		if performer != "" {
+			fmt.Fprintf(&b, "    PERFORMER %s\n", quoteCue(performer))
+		}
+		fmt.Fprintf(&b, "    INDEX 01 %s\n", formatCueTime(ch.Start))
+	}
+	return b.String()
+}
+
+// Export writes chapter files next to mediaPath and returns their paths
+func Export(chapters []Chapter, mediaPath string, formats []string, video *metadata.Video) ([]string, error) {
+	base := strings.TrimSuffix(mediaPath, filepath.Ext(mediaPath))
+
+	var written []string
+	for _, format := range formats {
+		var path, content string
+		switch format {
+		case FormatText:
+			path, content = base+".chapters.txt", Text(chapters)
+		case FormatFFMetadata:
+			path, content = base+".ffmetadata.txt", FFMetadata(chapters)
+		case FormatCue:
+			performer, title := "", ""
+			if video != nil {
+				performer, title = video.ChannelName(), video.Title
+			}
+			path, content = base+".cue", Cue(chapters, mediaPath, performer, title)
+		default:
+			return written, fmt.Errorf("unknown chapters format: %s", format)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return written, fmt.Errorf("chapters write error: %v", err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// escapeFFMetadata escapes the characters FFMETADATA treats specially
+func escapeFFMetadata(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", "\\\n")
+	return replacer.Replace(s)
+}
+
+// quoteCue quotes a cue sheet string; cue has no escaping for quotes
+func quoteCue(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "'") + `"`
+}
+
+// formatCueTime formats MM:SS:FF with 75 frames per second
+func formatCueTime(d time.Duration) string {
+	frames := d * 75 / time.Second
+	return fmt.Sprintf("%02d:%02d:%02d", frames/75/60, frames/75%60, frames%75)
+}
+
+// formatClock formats M:SS, or H:MM:SS when long is set
+func formatClock(d time.Duration, long bool) string {
+	total := int(d / time.Second)
+	if long {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, total/60%60, total%60)
+	}
+	return fmt.Sprintf("%02d:%02d", total/60, total%60)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,68 @@
+package chapters
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const description = `Tracklist:
+0:00 Intro
+1:30 First song
+12:05 - Second song
+not a chapter 99
+`
+
+func TestFromDescription(t *testing.T) {
+	chapters := FromDescription(description, 15*time.Minute)
+	want := []Chapter{
+		{Start: 0, End: 90 * time.Second, Title: "Intro"},
+		{Start: 90 * time.Second, End: 725 * time.Second, Title: "First song"},
+		{Start: 725 * time.Second, End: 15 * time.Minute, Title: "Second song"},
+	}
+	if !reflect.DeepEqual(chapters, want) {
+		t.Errorf("FromDescription = %+v, want %+v", chapters, want)
+	}
+
+	// Timestamps past the end are not chapters
+	if got := FromDescription(description, 10*time.Minute); got != nil {
+		t.Errorf("with a 10m duration = %+v, want nil (only two chapters fit)", got)
+	}
+}
+
+func TestFromDescriptionUnknownDuration(t *testing.T) {
+	chapters := FromDescription(description, 0)
+	if len(chapters) != 3 {
+		t.Fatalf("got %d chapters, want 3", len(chapters))
+	}
+	if last := chapters[2]; last.End != 0 {
+		t.Errorf("last chapter End = %s, want 0 (open)", last.End)
+	}
+	if !open(chapters) {
+		t.Error("open() = false with an open last chapter")
+	}
+
+	closed := closeOpen(chapters, 20*time.Minute)
+	if closed[2].End != 20*time.Minute || closed[1].End != 725*time.Second {
+		t.Errorf("closeOpen = %+v", closed)
+	}
+	if open(closed) {
+		t.Error("closeOpen left an open chapter")
+	}
+
+	// A media file shorter than the last start drops that chapter
+	if got := closeOpen(chapters, 10*time.Minute); len(got) != 2 {
+		t.Errorf("closeOpen with a shorter file kept %d chapters, want 2", len(got))
+	}
+}
+
+func TestFFMetadataEnds(t *testing.T) {
+	got := FFMetadata(closeOpen(FromDescription(description, 0), 16*time.Minute))
+	want := ";FFMETADATA1\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=0\nEND=90000\ntitle=Intro\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=90000\nEND=725000\ntitle=First song\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=725000\nEND=960000\ntitle=Second song\n"
+	if got != want {
+		t.Errorf("FFMetadata =\n%s\nwant\n%s", got, want)
+	}
+}
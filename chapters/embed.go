@@ -0,0 +1,146 @@
+package chapters
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"yt_downloader/metadata"
+	"yt_downloader/utils"
+)
+
+// embedContainers are formats ffmpeg can write chapters into
+var embedContainers = []string{"mp4", "m4a", "mov", "mkv", "webm", "mp3"}
+
+// Embed writes chapters into a media file, replacing chapters it had
+func Embed(ctx context.Context, mediaPath string, chapters []Chapter) error {
+	container := strings.ToLower(strings.TrimPrefix(filepath.Ext(mediaPath), "."))
+	supported := false
+	for _, c := range embedContainers {
+		if c == container {
+			supported = true
+		}
+	}
+	if !supported {
+		return fmt.Errorf("chapter embedding is not supported for .%s", container)
+	}
+
+	base := strings.TrimSuffix(mediaPath, filepath.Ext(mediaPath))
+	metaPath := base + ".chapters.ffmeta"
+	if err := os.WriteFile(metaPath, []byte(FFMetadata(chapters)), 0644); err != nil {
+		return fmt.Errorf("chapters write error: %v", err)
+	}
+	defer os.Remove(metaPath)
+
+	// Write to a temp file and replace the original on success
+	tmpPath := base + ".chapters" + filepath.Ext(mediaPath)
+	args := []string{"-y", "-i", mediaPath, "-f", "ffmetadata", "-i", metaPath,
+		"-map", "0", "-map_metadata", "0", "-map_chapters", "1", "-c", "copy",
+	}
+	if container == "mp3" {
+		args = append(args, "-id3v2_version", "3")
+	}
+	args = append(args, tmpPath)
+
+	cmd := exec.CommandContext(ctx, utils.GetFFmpegBinary(), args...)
+	if output, err := utils.CombinedOutputOf(cmd); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("ffmpeg error: %v\n%s", err, strings.TrimSpace(string(output)))
+	}
+
+	if err := os.Rename(tmpPath, mediaPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace media file: %v", err)
+	}
+	return nil
+}
+
+// Process looks up chapters for a downloaded file and embeds or exports
+// them. Edits run before that, e.g. to follow cuts made after download.
+func Process(ctx context.Context, url, mediaPath string, options Options, edits ...func([]Chapter) []Chapter) error {
+	if !options.Enabled() {
+		return nil
+	}
+
+	video, err := metadata.Fetch(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	chapters := FromMetadata(video)
+	for _, edit := range edits {
+		chapters = edit(chapters)
+	}
+	if open(chapters) {
+		// FFMETADATA needs an end for every chapter
+		duration, err := utils.ProbeDuration(ctx, mediaPath)
+		if err != nil {
+			return err
+		}
+		chapters = closeOpen(chapters, duration)
+	}
+	if len(chapters) == 0 {
+		fmt.Println("📑 No chapters found")
+		return nil
+	}
+	source := "video"
+	if len(video.Chapters) == 0 {
+		source = "description"
+	}
+	fmt.Printf("📑 Chapters: %d (from %s)\n", len(chapters), source)
+
+	if options.Embed {
+		if err := Embed(ctx, mediaPath, chapters); err != nil {
+			return err
+		}
+		fmt.Println("📑 Chapters embedded")
+	}
+
+	if len(options.Export) > 0 {
+		written, err := Export(chapters, mediaPath, options.Export, video)
+		for _, path := range written {
+			fmt.Printf("📑 Chapters saved: %s\n", filepath.Base(path))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PromptOptions asks how to handle chapters; audio offers a cue sheet
+func PromptOptions(forAudio bool) Options {
+	var options Options
+	var choice string
+
+	fmt.Println("\n📑 === CHAPTERS ===")
+	fmt.Println("Chapters (from the video, or from description timestamps):")
+	fmt.Println("1 - Skip (default)")
+	fmt.Println("2 - Embed into the file")
+	fmt.Println("3 - Embed and save as a text file")
+	fmt.Println("4 - Embed and save as FFMETADATA")
+	if forAudio {
+		fmt.Println("5 - Embed and save as a .cue sheet")
+	}
+	fmt.Print("Your choice: ")
+	fmt.Scanln(&choice)
+
+	switch choice {
+	case "2":
+		options.Embed = true
+	case "3":
+		options.Embed = true
+		options.Export = []string{FormatText}
+	case "4":
+		options.Embed = true
+		options.Export = []string{FormatFFMetadata}
+	case "5":
+		if forAudio {
+			options.Embed = true
+			options.Export = []string{FormatCue}
+		}
+	}
+	return options
+}
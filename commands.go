@@ -0,0 +1,521 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"yt_downloader/server"
+	"yt_downloader/subformat"
+	"yt_downloader/subtitles"
+	"yt_downloader/transcript"
+	"yt_downloader/utils"
+)
+
+const usage = `Usage:
+  yt-downloader                         interactive menu
+  yt-downloader subs convert [flags] FILE...
+      -to FORMAT   target format: srt, vtt, ass (default srt)
+      -o FILE      output file (single input only)
+  yt-downloader subs transcript [flags] FILE...
+      -format F    txt, md, json (default txt)
+      -timestamps  prefix paragraphs with timestamps (default true)
+      -id ID       YouTube video ID for timestamp links
+      -url URL     video URL (alternative to -id)
+      -title TEXT  transcript heading
+      -o FILE      output file (single input only)
+  yt-downloader subs shift [flags] FILE...
+      -by OFFSET   shift all cues, e.g. 1.5s, -250ms, -00:00:02.000
+      -range SPEC  shift cues starting in a range: START-END=OFFSET (repeatable)
+      -o FILE      output file (default: overwrite input)
+  yt-downloader subs sync -map FROM=TO -map FROM=TO [-o FILE] FILE
+      stretch timing linearly between two reference points
+  yt-downloader subs backfill [flags] FOLDER
+      -langs LIST  languages to fetch, comma separated, > for fallbacks,
+                   e.g. uk>ru>en,de (default en)
+      -format F    subtitle format: srt, vtt, ass (default srt)
+      -source S    caption source: manual, auto, fallback (default fallback)
+      -embed       embed fetched subtitles into the video
+      -keep        keep sidecar files after embedding (default true)
+      -history     resolve video IDs from download history (default true)
+      -search      search YouTube by file name when no ID is found
+      -dry-run     only list what is missing
+  yt-downloader subs matrix [flags] [FILE]
+      video × language availability for a batch file (default links.txt)
+      -langs LIST  languages to check, > for fallbacks (default: all found)
+      -source S    caption source for -drop: manual, auto, fallback (default fallback)
+      -csv FILE    export the matrix to CSV
+      -drop        comment out videos lacking a language from -langs
+  yt-downloader serve [-addr HOST:PORT] [-keep-partial]
+      run the web UI and HTTP API (default 127.0.0.1:8080): submit, pause,
+      reorder and cancel jobs, read history, list subtitles
+      -keep-partial  keep .part files of cancelled jobs for resuming
+
+Ctrl+C stops the current download, a second Ctrl+C exits at once. Partial
+files of a stopped download are removed unless KEEP_PARTIAL_FILES=1 is set.
+Batch and server downloads failing on network errors or rate limits are
+retried with growing pauses, see retry_policy.json.
+`
+
+// runCommand runs a non-interactive command and returns the exit code
+func runCommand(args []string) int {
+	switch args[0] {
+	case "subs":
+		return runSubsCommand(args[1:])
+	case "serve":
+		return runServe(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "⚠ Unknown command: %s\n\n%s", args[0], usage)
+		return 2
+	}
+}
+
+// runSubsCommand dispatches "subs" subcommands
+func runSubsCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	switch args[0] {
+	case "convert":
+		return runSubsConvert(args[1:])
+	case "transcript":
+		return runSubsTranscript(args[1:])
+	case "shift":
+		return runSubsShift(args[1:])
+	case "sync":
+		return runSubsSync(args[1:])
+	case "backfill":
+		return runSubsBackfill(args[1:])
+	case "matrix":
+		return runSubsMatrix(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "⚠ Unknown subs command: %s\n\n%s", args[0], usage)
+		return 2
+	}
+}
+
+// runSubsConvert converts subtitle files between SRT, VTT and ASS
+func runSubsConvert(args []string) int {
+	flags := flag.NewFlagSet("subs convert", flag.ContinueOnError)
+	to := flags.String("to", subformat.FormatSRT, "target format: srt, vtt, ass")
+	output := flags.String("o", "", "output file (single input only)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	inputs := flags.Args()
+	if len(inputs) == 0 {
+		fmt.Fprint(os.Stderr, "⚠ No input files\n\n"+usage)
+		return 2
+	}
+	if *output != "" && len(inputs) > 1 {
+		fmt.Fprintln(os.Stderr, "⚠ -o can only be used with a single input file")
+		return 2
+	}
+
+	format := strings.ToLower(*to)
+	if *output != "" {
+		format = strings.ToLower(strings.TrimPrefix(filepath.Ext(*output), "."))
+	}
+	if !subformat.IsSupported(format) {
+		fmt.Fprintf(os.Stderr, "⚠ Unsupported subtitle format: %s\n", format)
+		return 2
+	}
+
+	failed := 0
+	for _, input := range inputs {
+		target := *output
+		if target == "" {
+			target = strings.TrimSuffix(input, filepath.Ext(input)) + "." + format
+		}
+		if target == input {
+			fmt.Printf("⏭ %s is already %s\n", input, strings.ToUpper(format))
+			continue
+		}
+
+		if err := subformat.ConvertFile(input, target); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %s -> %s\n", input, target)
+	}
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runSubsTranscript builds transcripts from existing subtitle files
+func runSubsTranscript(args []string) int {
+	flags := flag.NewFlagSet("subs transcript", flag.ContinueOnError)
+	format := flags.String("format", transcript.FormatText, "txt, md, json")
+	timestamps := flags.Bool("timestamps", true, "prefix paragraphs with timestamps")
+	videoID := flags.String("id", "", "YouTube video ID for timestamp links")
+	videoURL := flags.String("url", "", "video URL (alternative to -id)")
+	title := flags.String("title", "", "transcript heading")
+	output := flags.String("o", "", "output file (single input only)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	inputs := flags.Args()
+	if len(inputs) == 0 {
+		fmt.Fprint(os.Stderr, "⚠ No input files\n\n"+usage)
+		return 2
+	}
+	if *output != "" && len(inputs) > 1 {
+		fmt.Fprintln(os.Stderr, "⚠ -o can only be used with a single input file")
+		return 2
+	}
+	if !transcript.IsSupported(*format) {
+		fmt.Fprintf(os.Stderr, "⚠ Unsupported transcript format: %s\n", *format)
+		return 2
+	}
+
+	id := *videoID
+	if id == "" && *videoURL != "" {
+		id = utils.ExtractVideoID(*videoURL)
+	}
+
+	failed := 0
+	for _, input := range inputs {
+		target := *output
+		if target == "" {
+			target = transcript.OutputPath(input, *format)
+		}
+
+		options := transcript.Options{
+			Format:     *format,
+			Timestamps: *timestamps,
+			VideoID:    id,
+			Title:      *title,
+		}
+		if err := transcript.BuildFile(input, target, options); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %s -> %s\n", input, target)
+	}
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// stringList collects repeated string flags
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// runSubsShift applies constant or per-range offsets to subtitle files
+func runSubsShift(args []string) int {
+	flags := flag.NewFlagSet("subs shift", flag.ContinueOnError)
+	by := flags.String("by", "", "offset for all cues")
+	output := flags.String("o", "", "output file (default: overwrite input)")
+	var ranges stringList
+	flags.Var(&ranges, "range", "START-END=OFFSET, repeatable")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	inputs := flags.Args()
+	if len(inputs) == 0 || (*by == "" && len(ranges) == 0) {
+		fmt.Fprint(os.Stderr, "⚠ Need input files and -by or -range\n\n"+usage)
+		return 2
+	}
+	if *output != "" && len(inputs) > 1 {
+		fmt.Fprintln(os.Stderr, "⚠ -o can only be used with a single input file")
+		return 2
+	}
+
+	var offset time.Duration
+	if *by != "" {
+		var err error
+		if offset, err = subformat.ParseOffset(*by); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ %v\n", err)
+			return 2
+		}
+	}
+
+	var shifts []subformat.RangeShift
+	for _, spec := range ranges {
+		r, rangeOffset, err := parseRangeSpec(spec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ %v\n", err)
+			return 2
+		}
+		shifts = append(shifts, subformat.RangeShift{Range: r, Offset: rangeOffset})
+	}
+
+	return editSubtitleFiles(inputs, *output, func(doc *subformat.Document) error {
+		// Ranges refer to the original timing, so apply them first
+		doc.ShiftRanges(shifts)
+		if offset != 0 {
+			doc.Shift(offset)
+		}
+		return nil
+	})
+}
+
+// runSubsSync stretches subtitle timing between two reference points
+func runSubsSync(args []string) int {
+	flags := flag.NewFlagSet("subs sync", flag.ContinueOnError)
+	output := flags.String("o", "", "output file (default: overwrite input)")
+	var maps stringList
+	flags.Var(&maps, "map", "FROM=TO reference point, exactly two")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	inputs := flags.Args()
+	if len(inputs) != 1 || len(maps) != 2 {
+		fmt.Fprint(os.Stderr, "⚠ Need one input file and exactly two -map points\n\n"+usage)
+		return 2
+	}
+
+	var points []subformat.SyncPoint
+	for _, spec := range maps {
+		from, to, found := strings.Cut(spec, "=")
+		if !found {
+			fmt.Fprintf(os.Stderr, "⚠ Invalid -map %q, want FROM=TO\n", spec)
+			return 2
+		}
+		fromTime, err := subformat.ParseTime(from)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ %v\n", err)
+			return 2
+		}
+		toTime, err := subformat.ParseTime(to)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ %v\n", err)
+			return 2
+		}
+		points = append(points, subformat.SyncPoint{From: fromTime, To: toTime})
+	}
+
+	return editSubtitleFiles(inputs, *output, func(doc *subformat.Document) error {
+		return doc.Sync(points[0], points[1])
+	})
+}
+
+// parseRangeSpec parses "START-END=OFFSET"
+func parseRangeSpec(spec string) (subformat.TimeRange, time.Duration, error) {
+	bounds, offsetStr, found := strings.Cut(spec, "=")
+	if !found {
+		return subformat.TimeRange{}, 0, fmt.Errorf("invalid -range %q, want START-END=OFFSET", spec)
+	}
+	startStr, endStr, found := strings.Cut(bounds, "-")
+	if !found {
+		return subformat.TimeRange{}, 0, fmt.Errorf("invalid -range %q, want START-END=OFFSET", spec)
+	}
+
+	start, err := subformat.ParseTime(startStr)
+	if err != nil {
+		return subformat.TimeRange{}, 0, err
+	}
+	end, err := subformat.ParseTime(endStr)
+	if err != nil {
+		return subformat.TimeRange{}, 0, err
+	}
+	if end <= start {
+		return subformat.TimeRange{}, 0, fmt.Errorf("invalid -range %q: end before start", spec)
+	}
+	offset, err := subformat.ParseOffset(offsetStr)
+	if err != nil {
+		return subformat.TimeRange{}, 0, err
+	}
+
+	return subformat.TimeRange{Start: start, End: end}, offset, nil
+}
+
+// editSubtitleFiles parses each input, applies edit and writes the result
+// to output (or back to the input)
+func editSubtitleFiles(inputs []string, output string, edit func(*subformat.Document) error) int {
+	failed := 0
+	for _, input := range inputs {
+		target := output
+		if target == "" {
+			target = input
+		}
+
+		doc, err := subformat.ParseFile(input)
+		if err == nil {
+			err = edit(doc)
+		}
+		if err == nil {
+			err = doc.WriteFile(target)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ %s: %v\n", input, err)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %s -> %s\n", input, target)
+	}
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runSubsBackfill fetches missing subtitles for videos already on disk
+func runSubsBackfill(args []string) int {
+	flags := flag.NewFlagSet("subs backfill", flag.ContinueOnError)
+	langs := flags.String("langs", "en", "languages to fetch, e.g. uk>ru>en,de")
+	format := flags.String("format", subformat.FormatSRT, "srt, vtt, ass")
+	source := flags.String("source", subtitles.CaptionsFallback, "manual, auto, fallback")
+	embed := flags.Bool("embed", false, "embed fetched subtitles into the video")
+	keep := flags.Bool("keep", true, "keep sidecar files after embedding")
+	useHistory := flags.Bool("history", true, "resolve video IDs from download history")
+	search := flags.Bool("search", false, "search YouTube by file name when no ID is found")
+	dryRun := flags.Bool("dry-run", false, "only list what is missing")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 1 {
+		fmt.Fprint(os.Stderr, "⚠ Need exactly one folder\n\n"+usage)
+		return 2
+	}
+	if !subformat.IsSupported(*format) {
+		fmt.Fprintf(os.Stderr, "⚠ Unsupported subtitle format: %s\n", *format)
+		return 2
+	}
+	switch *source {
+	case subtitles.CaptionsManual, subtitles.CaptionsAuto, subtitles.CaptionsFallback:
+	default:
+		fmt.Fprintf(os.Stderr, "⚠ Unknown caption source: %s\n", *source)
+		return 2
+	}
+
+	subOptions := subtitles.DefaultSubtitleOptions
+	subOptions.DownloadSubtitles = true
+	subOptions.SubtitleFormat = *format
+	subOptions.CaptionSource = *source
+	subOptions.EmbedSubtitles = *embed
+	subOptions.RemoveSidecars = !*keep
+	if strings.TrimSpace(*langs) == "all" {
+		subOptions.DownloadAll = true
+	} else {
+		languages, fallbacks, err := subtitles.ParseLanguageList(*langs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ %v\n", err)
+			return 2
+		}
+		subOptions.Languages = languages
+		subOptions.Fallbacks = fallbacks
+		subOptions.DefaultLanguage = subOptions.Languages[0]
+	}
+
+	options := subtitles.BackfillOptions{
+		Subtitles:     subOptions,
+		UseHistory:    *useHistory,
+		SearchByTitle: *search,
+		DryRun:        *dryRun,
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+
+	if err := subtitles.Backfill(ctx, flags.Arg(0), options); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runSubsMatrix reports subtitle availability for every URL in a batch file
+func runSubsMatrix(args []string) int {
+	flags := flag.NewFlagSet("subs matrix", flag.ContinueOnError)
+	langs := flags.String("langs", "", "languages to check, e.g. uk>ru>en,de")
+	source := flags.String("source", subtitles.CaptionsFallback, "manual, auto, fallback")
+	csvPath := flags.String("csv", "", "export the matrix to CSV")
+	drop := flags.Bool("drop", false, "comment out videos lacking a language")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	filePath := "links.txt"
+	if flags.NArg() > 0 {
+		filePath = flags.Arg(0)
+	}
+
+	options := subtitles.SubtitleOptions{CaptionSource: *source}
+	if *langs != "" {
+		languages, fallbacks, err := subtitles.ParseLanguageList(*langs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ %v\n", err)
+			return 2
+		}
+		options.Languages = languages
+		options.Fallbacks = fallbacks
+	}
+	if *drop && len(options.Languages) == 0 {
+		fmt.Fprintln(os.Stderr, "⚠ -drop needs -langs")
+		return 2
+	}
+
+	urls := readBatchURLs(filePath)
+	if len(urls) == 0 {
+		return 1
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	matrix := subtitles.BuildAvailabilityMatrix(ctx, urls, options.Languages)
+	matrix.Print()
+
+	if *csvPath != "" {
+		if err := matrix.WriteCSV(*csvPath); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ %v\n", err)
+			return 1
+		}
+		fmt.Printf("✅ Report saved: %s\n", *csvPath)
+	}
+
+	if *drop {
+		_, dropped := matrix.Filter(options)
+		if len(dropped) > 0 {
+			if err := commentOutBatchURLs(filePath, dropped, "no subtitles"); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠ %v\n", err)
+				return 1
+			}
+		}
+		fmt.Printf("✅ Removed %d video(s) from %s\n", len(dropped), filePath)
+	}
+	return 0
+}
+
+// runServe runs the HTTP API until interrupted
+func runServe(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", "127.0.0.1:8080", "listen address")
+	keepPartial := flags.Bool("keep-partial", utils.KeepPartialFiles, "keep partial files of cancelled jobs")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	utils.KeepPartialFiles = *keepPartial
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	utils.CheckUpdateYtDlp()
+	if err := server.Run(ctx, *addr); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ %v\n", err)
+		return 1
+	}
+	return 0
+}
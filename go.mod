@@ -6,6 +6,7 @@ require (
 	github.com/go-audio/audio v1.0.0
 	github.com/go-audio/wav v1.1.0
 	github.com/hajimehoshi/oto/v2 v2.4.3
+	golang.org/x/text v0.26.0
 )
 
 require (
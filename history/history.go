@@ -11,12 +11,25 @@ const historyFile = "download_history.json"
 
 // SaveToHistory appends a record about a downloaded file into history
 func SaveToHistory(url, fileName, downloadTime string) {
-	history := LoadHistory()
-	history = append(history, map[string]string{
+	SaveToHistoryWithDetails(url, fileName, downloadTime, nil)
+}
+
+// SaveToHistoryWithDetails appends a record with extra fields
+// (e.g. "subtitles", "subtitles_missing")
+func SaveToHistoryWithDetails(url, fileName, downloadTime string, details map[string]string) {
+	record := map[string]string{
 		"url":           url,
 		"file_name":     fileName,
 		"download_time": downloadTime,
-	})
+	}
+	for key, value := range details {
+		if _, reserved := record[key]; !reserved {
+			record[key] = value
+		}
+	}
+
+	history := LoadHistory()
+	history = append(history, record)
 	data, err := json.MarshalIndent(history, "", "  ")
 	if err != nil {
 		fmt.Println("⚠ Failed to save download history:", err)
@@ -0,0 +1,146 @@
+package library
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"yt_downloader/metadata"
+	"yt_downloader/utils"
+)
+
+// Options controls media-server sidecar output
+type Options struct {
+	NFO      bool // Kodi/Jellyfin .nfo
+	InfoJSON bool // raw yt-dlp metadata as .info.json
+	Poster   bool // thumbnail image next to the media file
+	Layout   bool // Channel/Season YEAR/Channel SYYYYEMMDDhhmmss - Title
+}
+
+// Enabled reports whether anything besides the media file is wanted
+func (o Options) Enabled() bool {
+	return o.NFO || o.InfoJSON || o.Poster || o.Layout
+}
+
+// Item is where one download goes and what it is
+type Item struct {
+	Folder   string
+	Filename string // without extension
+	Video    *metadata.Video
+	Episode  bool // placed in the show/season layout
+}
+
+// Place works out folder and file name for a video. With Layout the video
+// is treated as an episode: the channel is the show and the upload year
+// is the season, which is how Jellyfin and Kodi group YouTube libraries.
+func Place(root string, video *metadata.Video, options Options) Item {
+	item := Item{
+		Folder:   root,
+		Filename: utils.SanitizeFileName(video.Title),
+		Video:    video,
+	}
+	if !options.Layout {
+		return item
+	}
+
+	show := utils.SanitizeFileName(video.ChannelName())
+	uploaded, ok := video.Uploaded()
+	if !ok {
+		item.Folder = filepath.Join(root, show, "Season 00") // specials
+		return item
+	}
+
+	item.Episode = true
+	item.Folder = filepath.Join(root, show, fmt.Sprintf("Season %d", uploaded.Year()))
+	item.Filename = utils.SanitizeFileName(fmt.Sprintf("%s S%dE%010d - %s",
+		show, uploaded.Year(), episodeNumber(video, uploaded), video.Title))
+	return item
+}
+
+// episodeNumber numbers an episode within its season by upload date, with
+// the upload time as the tiebreaker so same-day videos get their own
+// numbers: MMDDhhmmss. Without an exact time the playlist index stands in.
+func episodeNumber(video *metadata.Video, uploaded time.Time) int {
+	number := (int(uploaded.Month())*100 + uploaded.Day()) * 1000000
+	if video.Timestamp > 0 {
+		return number + uploaded.Hour()*10000 + uploaded.Minute()*100 + uploaded.Second()
+	}
+	return number + video.PlaylistIndex%1000000
+}
+
+// WriteSidecars writes the enabled sidecar files for a downloaded item
+func WriteSidecars(ctx context.Context, item Item, options Options) error {
+	if err := os.MkdirAll(item.Folder, 0755); err != nil {
+		return fmt.Errorf("folder create error: %v", err)
+	}
+
+	var failed []error
+	if options.NFO {
+		if err := writeNFO(item); err != nil {
+			failed = append(failed, err)
+		} else {
+			fmt.Printf("📄 NFO: %s.nfo\n", item.Filename)
+		}
+		if item.Episode {
+			if err := writeShowNFO(filepath.Dir(item.Folder), item.Video); err != nil {
+				failed = append(failed, err)
+			}
+		}
+	}
+
+	if options.InfoJSON {
+		path := filepath.Join(item.Folder, item.Filename+".info.json")
+		if err := os.WriteFile(path, item.Video.Raw, 0644); err != nil {
+			failed = append(failed, fmt.Errorf("info JSON write error: %v", err))
+		} else {
+			fmt.Printf("📄 Info JSON: %s\n", filepath.Base(path))
+		}
+	}
+
+	if options.Poster {
+		path, err := downloadPoster(ctx, item)
+		if err != nil {
+			failed = append(failed, err)
+		} else {
+			fmt.Printf("🖼 Poster: %s\n", filepath.Base(path))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("sidecar errors: %v", failed)
+	}
+	return nil
+}
+
+// PromptOptions asks which media-server files to write
+func PromptOptions() Options {
+	var options Options
+	var choice string
+
+	fmt.Println("\n📚 === MEDIA SERVER FILES ===")
+	fmt.Println("Write files for Jellyfin/Kodi?")
+	fmt.Println("1 - No (default)")
+	fmt.Println("2 - NFO and poster")
+	fmt.Println("3 - NFO, poster and info JSON")
+	fmt.Print("Your choice: ")
+	fmt.Scanln(&choice)
+
+	switch choice {
+	case "2":
+		options.NFO, options.Poster = true, true
+	case "3":
+		options.NFO, options.Poster, options.InfoJSON = true, true, true
+	default:
+		return options
+	}
+
+	fmt.Println("\nOrganize as a TV library (Channel/Season YEAR)?")
+	fmt.Println("1 - No, save into the chosen folder (default)")
+	fmt.Println("2 - Yes")
+	fmt.Print("Your choice: ")
+	fmt.Scanln(&choice)
+	options.Layout = choice == "2"
+
+	return options
+}
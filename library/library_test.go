@@ -0,0 +1,52 @@
+package library
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+	"yt_downloader/metadata"
+)
+
+func TestEpisodeNumbers(t *testing.T) {
+	morning := time.Date(2024, 3, 7, 9, 5, 30, 0, time.UTC)
+	evening := time.Date(2024, 3, 7, 18, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		video metadata.Video
+		want  int
+	}{
+		{"timestamp", metadata.Video{Timestamp: morning.Unix()}, 307090530},
+		{"same day later", metadata.Video{Timestamp: evening.Unix()}, 307180000},
+		{"date only", metadata.Video{UploadDate: "20240307"}, 307000000},
+		{"date and playlist index", metadata.Video{UploadDate: "20240307", PlaylistIndex: 12}, 307000012},
+		{"last second of the year", metadata.Video{Timestamp: time.Date(2024, 12, 31, 23, 59, 59, 0, time.UTC).Unix()}, 1231235959},
+	}
+	for _, tt := range tests {
+		uploaded, ok := tt.video.Uploaded()
+		if !ok {
+			t.Fatalf("%s: no upload date", tt.name)
+		}
+		if got := episodeNumber(&tt.video, uploaded); got != tt.want {
+			t.Errorf("%s: episodeNumber = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPlaceSameDayEpisodes(t *testing.T) {
+	options := Options{Layout: true}
+	first := &metadata.Video{Title: "Part 1", Channel: "Chan", Timestamp: time.Date(2024, 3, 7, 9, 0, 0, 0, time.UTC).Unix()}
+	second := &metadata.Video{Title: "Part 2", Channel: "Chan", Timestamp: time.Date(2024, 3, 7, 15, 30, 0, 0, time.UTC).Unix()}
+
+	a, b := Place("lib", first, options), Place("lib", second, options)
+	if a.Folder != filepath.Join("lib", "Chan", "Season 2024") || !a.Episode {
+		t.Errorf("Place = %+v", a)
+	}
+	if a.Filename != "Chan S2024E0307090000 - Part 1" || b.Filename != "Chan S2024E0307153000 - Part 2" {
+		t.Errorf("file names = %q, %q", a.Filename, b.Filename)
+	}
+
+	undated := Place("lib", &metadata.Video{Title: "Old", Channel: "Chan"}, options)
+	if undated.Episode || undated.Folder != filepath.Join("lib", "Chan", "Season 00") {
+		t.Errorf("undated Place = %+v", undated)
+	}
+}
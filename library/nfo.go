@@ -0,0 +1,102 @@
+package library
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"yt_downloader/metadata"
+)
+
+// uniqueID ties an NFO to its YouTube video or channel
+type uniqueID struct {
+	Type    string `xml:"type,attr"`
+	Default bool   `xml:"default,attr"`
+	Value   string `xml:",chardata"`
+}
+
+// nfo is a Kodi <movie> or <episodedetails> document
+type nfo struct {
+	XMLName   xml.Name
+	Title     string   `xml:"title"`
+	ShowTitle string   `xml:"showtitle,omitempty"`
+	Season    int      `xml:"season,omitempty"`
+	Episode   int      `xml:"episode,omitempty"`
+	Plot      string   `xml:"plot,omitempty"`
+	Premiered string   `xml:"premiered,omitempty"`
+	Aired     string   `xml:"aired,omitempty"`
+	Year      int      `xml:"year,omitempty"`
+	Studio    string   `xml:"studio,omitempty"`
+	Runtime   int      `xml:"runtime,omitempty"` // minutes
+	Genres    []string `xml:"genre"`
+	Tags      []string `xml:"tag"`
+	UniqueID  uniqueID `xml:"uniqueid"`
+}
+
+// showNFO is tvshow.nfo for a channel folder
+type showNFO struct {
+	XMLName  xml.Name `xml:"tvshow"`
+	Title    string   `xml:"title"`
+	Studio   string   `xml:"studio,omitempty"`
+	UniqueID uniqueID `xml:"uniqueid"`
+}
+
+// writeNFO writes "<filename>.nfo" next to the media file
+func writeNFO(item Item) error {
+	video := item.Video
+	doc := nfo{
+		XMLName:  xml.Name{Local: "movie"},
+		Title:    video.Title,
+		Plot:     video.Description,
+		Studio:   video.ChannelName(),
+		Runtime:  int(video.Length().Minutes() + 0.5),
+		Genres:   video.Categories,
+		Tags:     video.Tags,
+		UniqueID: uniqueID{Type: "youtube", Default: true, Value: video.ID},
+	}
+
+	if uploaded, ok := video.Uploaded(); ok {
+		doc.Premiered = uploaded.Format("2006-01-02")
+		doc.Year = uploaded.Year()
+	}
+
+	if item.Episode {
+		doc.XMLName.Local = "episodedetails"
+		doc.ShowTitle = video.ChannelName()
+		doc.Aired = doc.Premiered
+		if uploaded, ok := video.Uploaded(); ok {
+			doc.Season = uploaded.Year()
+			doc.Episode = episodeNumber(video, uploaded)
+		}
+	}
+
+	return writeXML(filepath.Join(item.Folder, item.Filename+".nfo"), doc)
+}
+
+// writeShowNFO writes tvshow.nfo for the channel once
+func writeShowNFO(showFolder string, video *metadata.Video) error {
+	path := filepath.Join(showFolder, "tvshow.nfo")
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	return writeXML(path, showNFO{
+		Title:    video.ChannelName(),
+		Studio:   video.ChannelName(),
+		UniqueID: uniqueID{Type: "youtube", Default: true, Value: video.ChannelID},
+	})
+}
+
+// writeXML writes an indented XML document with the standard header
+func writeXML(path string, doc interface{}) error {
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("NFO build error: %v", err)
+	}
+
+	data = append([]byte(xml.Header), data...)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("NFO write error: %v", err)
+	}
+	return nil
+}
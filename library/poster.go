@@ -0,0 +1,46 @@
+package library
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"yt_downloader/metadata"
+	"yt_downloader/thumbnail"
+)
+
+// downloadPoster saves the best thumbnail as "<filename>-thumb.<ext>" for
+// episodes and "<filename>-poster.<ext>" for movies, the names Kodi and
+// Jellyfin pick up automatically
+func downloadPoster(ctx context.Context, item Item) (string, error) {
+	thumb, ok := posterThumbnail(item.Video)
+	if !ok {
+		return "", fmt.Errorf("no thumbnail available")
+	}
+
+	suffix := "-poster"
+	if item.Episode {
+		suffix = "-thumb"
+	}
+	target, err := thumbnail.Fetch(ctx, thumb, filepath.Join(item.Folder, item.Filename+suffix))
+	if err != nil {
+		return "", fmt.Errorf("poster: %w", err)
+	}
+	return target, nil
+}
+
+// posterThumbnail prefers the best JPEG, which every media server reads,
+// over WebP variants
+func posterThumbnail(video *metadata.Video) (metadata.Thumbnail, bool) {
+	var jpegs []metadata.Thumbnail
+	for _, thumb := range video.Thumbnails {
+		if strings.HasSuffix(strings.SplitN(thumb.URL, "?", 2)[0], ".jpg") {
+			jpegs = append(jpegs, thumb)
+		}
+	}
+	if len(jpegs) > 0 {
+		best := metadata.Video{Thumbnails: jpegs}
+		return best.BestThumbnail()
+	}
+	return video.BestThumbnail()
+}
@@ -0,0 +1,45 @@
+package library
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"yt_downloader/metadata"
+)
+
+func TestDownloadPoster(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	video := &metadata.Video{Thumbnails: []metadata.Thumbnail{
+		{URL: server.URL + "/maxres.webp", Preference: 1, Width: 1280, Height: 720},
+		{URL: server.URL + "/hq.jpg", Width: 480, Height: 360},
+	}}
+	folder := t.TempDir()
+
+	movie, err := downloadPoster(context.Background(), Item{Folder: folder, Filename: "Film", Video: video})
+	if err != nil {
+		t.Fatal(err)
+	}
+	episode, err := downloadPoster(context.Background(), Item{Folder: folder, Filename: "Show S2024E0307090000", Video: video, Episode: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The JPEG wins over the larger WebP
+	if filepath.Base(movie) != "Film-poster.jpg" || filepath.Base(episode) != "Show S2024E0307090000-thumb.jpg" {
+		t.Errorf("posters = %s, %s", filepath.Base(movie), filepath.Base(episode))
+	}
+	if data, _ := os.ReadFile(movie); string(data) != "/hq.jpg" {
+		t.Errorf("poster content = %q, want the JPEG", data)
+	}
+
+	if _, err := downloadPoster(context.Background(), Item{Folder: folder, Filename: "None", Video: &metadata.Video{}}); err == nil {
+		t.Error("downloadPoster without thumbnails succeeded")
+	}
+}
@@ -2,18 +2,35 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
-	"time"
+	"syscall"
 	"yt_downloader/audio"
+	"yt_downloader/chapters"
+	"yt_downloader/library"
+	"yt_downloader/mediatags"
+	"yt_downloader/metadata"
+	"yt_downloader/sponsorblock"
 	"yt_downloader/subtitles"
+	"yt_downloader/thumbnail"
 	"yt_downloader/utils"
 	"yt_downloader/video"
 )
 
 func main() {
+	// Non-interactive commands, e.g. "yt-downloader subs convert ..."
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
 	fmt.Println("🎬 YouTube Downloader v2.0")
 	fmt.Println("==========================")
 
@@ -35,16 +52,20 @@ func main() {
 	fmt.Println("\n📋 What do you want to download?")
 	fmt.Println("1 - Audio (MP3)")
 	fmt.Println("2 - Video (MP4/WebM)")
+	fmt.Println("3 - Subtitles only (no video)")
 	fmt.Print("Your choice: ")
 	fmt.Scanln(&contentType)
 
 	switch contentType {
 	case "1":
 		fmt.Println("\n🎵 === AUDIO DOWNLOAD MODE ===")
-		handleAudioDownload()
+		handleAudioDownload(ctx)
 	case "2":
 		fmt.Println("\n🎬 === VIDEO DOWNLOAD MODE ===")
-		handleVideoDownload()
+		handleVideoDownload(ctx)
+	case "3":
+		fmt.Println("\n📝 === SUBTITLES ONLY MODE ===")
+		handleSubtitlesDownload(ctx)
 	default:
 		fmt.Println("⚠ Invalid choice. Exiting.")
 		return
@@ -56,8 +77,11 @@ func main() {
 }
 
 // handleAudioDownload handles audio download flow
-func handleAudioDownload() {
+func handleAudioDownload(ctx context.Context) {
 	audio.PromptAudioQuality()
+	audio.Thumbnail = thumbnail.PromptOptions(true)
+	audio.Chapters = chapters.PromptOptions(true)
+	audio.SponsorBlock = sponsorblock.PromptOptions()
 
 	var mode string
 	fmt.Println("\n📥 Select download mode:")
@@ -79,13 +103,19 @@ func handleAudioDownload() {
 
 		folder := chooseDownloadFolder()
 		fmt.Println("\n🔍 Fetching video info...")
-		fileName := audio.GetTitleFromURL(url)
+		fileName, err := audio.GetTitleFromURL(ctx, url)
+		if err != nil {
+			printDownloadError(err)
+			return
+		}
 		fmt.Printf("📁 Output file: %s.mp3\n", fileName)
-		audio.DownloadAudio(url, fileName, folder)
+		if err := audio.DownloadAudio(ctx, url, fileName, folder); err != nil && ctx.Err() == nil {
+			printDownloadError(err)
+		}
 
 	case "2":
 		folder := chooseDownloadFolder()
-		audio.ProcessBatchFile("links.txt", folder)
+		processAudioBatchFile(ctx, "links.txt", folder)
 
 	default:
 		fmt.Println("⚠ Invalid mode selection.")
@@ -93,15 +123,21 @@ func handleAudioDownload() {
 }
 
 // handleVideoDownload handles video download flow
-func handleVideoDownload() {
+func handleVideoDownload(ctx context.Context) {
 	video.PromptVideoQuality()
+	video.Thumbnail = thumbnail.PromptOptions(false)
+	video.Chapters = chapters.PromptOptions(false)
+	video.SponsorBlock = sponsorblock.PromptOptions()
+	video.Tags = mediatags.PromptOptions()
 	subOptions := subtitles.PromptSubtitleOptions()
+	libOptions := library.PromptOptions()
 
 	var mode string
 	fmt.Println("\n📥 Select download mode:")
 	fmt.Println("1 - Single URL")
 	fmt.Println("2 - Batch from file")
 	fmt.Println("3 - List available subtitles (no download)")
+	fmt.Println("4 - Subtitle availability report for batch file")
 	fmt.Print("Your choice: ")
 	fmt.Scanln(&mode)
 
@@ -117,18 +153,17 @@ func handleVideoDownload() {
 		}
 
 		if subOptions.DownloadSubtitles {
-			subtitles.ShowAvailableSubtitles(url)
+			subtitles.ShowAvailableSubtitles(ctx, url)
 		}
 
 		folder := chooseDownloadFolder()
-		fmt.Println("\n🔍 Fetching video info...")
-		fileName := video.GetVideoTitle(url)
-		fmt.Printf("📁 Output file: %s\n", fileName)
-		video.DownloadVideoWithSubtitles(url, fileName, folder, subOptions)
+		if _, err := downloadVideoItem(ctx, url, folder, subOptions, libOptions); err != nil && ctx.Err() == nil {
+			printDownloadError(err)
+		}
 
 	case "2":
 		folder := chooseDownloadFolder()
-		processVideoBatchFileWithSubtitles("links.txt", folder, subOptions)
+		processVideoBatchFileWithSubtitles(ctx, "links.txt", folder, subOptions, libOptions)
 
 	case "3":
 		fmt.Print("\n🔗 Enter video URL: ")
@@ -140,7 +175,58 @@ func handleVideoDownload() {
 			return
 		}
 
-		subtitles.ShowAvailableSubtitles(url)
+		subtitles.ShowAvailableSubtitles(ctx, url)
+
+	case "4":
+		reportSubtitleAvailability(ctx, "links.txt", subOptions)
+
+	default:
+		fmt.Println("⚠ Invalid mode selection.")
+	}
+}
+
+// handleSubtitlesDownload handles subtitles-only download flow
+func handleSubtitlesDownload(ctx context.Context) {
+	subOptions := subtitles.PromptSubtitleOnlyOptions()
+
+	var mode string
+	fmt.Println("\n📥 Select download mode:")
+	fmt.Println("1 - Single URL")
+	fmt.Println("2 - Batch from file")
+	fmt.Println("3 - Subtitle availability report for batch file")
+	fmt.Print("Your choice: ")
+	fmt.Scanln(&mode)
+
+	switch mode {
+	case "1":
+		fmt.Print("\n🔗 Enter video URL: ")
+		var url string
+		fmt.Scanln(&url)
+
+		if !utils.IsValidURL(url) {
+			fmt.Println("⚠ Invalid URL format")
+			return
+		}
+
+		subtitles.ShowAvailableSubtitles(ctx, url)
+
+		folder := chooseDownloadFolder()
+		fmt.Println("\n🔍 Fetching video info...")
+		fileName, err := video.GetVideoTitle(ctx, url)
+		if err != nil {
+			printDownloadError(err)
+			return
+		}
+		if err := subtitles.DownloadSubtitlesOnly(ctx, url, fileName, folder, subOptions); err != nil && ctx.Err() == nil {
+			printDownloadError(err)
+		}
+
+	case "2":
+		folder := chooseDownloadFolder()
+		processSubtitlesBatchFile(ctx, "links.txt", folder, subOptions)
+
+	case "3":
+		reportSubtitleAvailability(ctx, "links.txt", subOptions)
 
 	default:
 		fmt.Println("⚠ Invalid mode selection.")
@@ -168,12 +254,12 @@ func chooseDownloadFolder() string {
 	}
 }
 
-// processVideoBatchFileWithSubtitles handles batch video downloads
-func processVideoBatchFileWithSubtitles(filePath string, folder string, subOptions subtitles.SubtitleOptions) {
+// readBatchURLs reads URLs from a batch file, skipping blanks and comments
+func readBatchURLs(filePath string) []string {
 	file, err := os.Open(filePath)
 	if err != nil {
 		fmt.Printf("⚠ Failed to open file: %s\n", filePath)
-		return
+		return nil
 	}
 	defer file.Close()
 
@@ -189,26 +275,218 @@ func processVideoBatchFileWithSubtitles(filePath string, folder string, subOptio
 
 	if err := scanner.Err(); err != nil {
 		fmt.Printf("⚠ File read error: %v\n", err)
-		return
+		return nil
 	}
 
 	if len(urls) == 0 {
 		fmt.Println("⚠ No valid URLs found in file")
+	}
+	return urls
+}
+
+// commentOutBatchURLs disables URLs in a batch file by turning their lines
+// into comments, so they can be restored by hand later
+func commentOutBatchURLs(filePath string, urls []string, reason string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("batch file read error: %v", err)
+	}
+
+	drop := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		drop[url] = true
+	}
+
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		if drop[strings.TrimSpace(line)] {
+			lines[i] = "# " + reason + ": " + strings.TrimSpace(line)
+		}
+	}
+
+	if err := os.WriteFile(filePath, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		return fmt.Errorf("batch file write error: %v", err)
+	}
+	return nil
+}
+
+// reportSubtitleAvailability prints a video × language matrix for a batch
+// file and optionally drops videos lacking the requested languages
+func reportSubtitleAvailability(ctx context.Context, filePath string, subOptions subtitles.SubtitleOptions) {
+	urls := readBatchURLs(filePath)
+	if len(urls) == 0 {
+		return
+	}
+
+	var languages []string
+	if !subOptions.DownloadAll {
+		languages = subOptions.Languages
+	}
+
+	fmt.Printf("📋 Checking subtitles for %d videos\n", len(urls))
+	matrix := subtitles.BuildAvailabilityMatrix(ctx, urls, languages)
+	matrix.Print()
+
+	var choice string
+	fmt.Println("\nExport the report to CSV?")
+	fmt.Println("1 - No (default)")
+	fmt.Println("2 - Yes")
+	fmt.Print("Your choice: ")
+	fmt.Scanln(&choice)
+
+	if choice == "2" {
+		csvPath := "subtitles_availability.csv"
+		if err := matrix.WriteCSV(csvPath); err != nil {
+			fmt.Printf("⚠ Error: %v\n", err)
+		} else {
+			fmt.Printf("✅ Report saved: %s\n", csvPath)
+		}
+	}
+
+	if len(languages) == 0 {
+		return
+	}
+
+	_, dropped := matrix.Filter(subOptions)
+	if len(dropped) == 0 {
+		fmt.Println("✅ Every video has the requested languages")
 		return
 	}
 
-	fmt.Printf("📋 Found %d videos to download\n", len(urls))
+	fmt.Printf("\n⚠ Videos lacking a requested language: %d\n", len(dropped))
+	for _, url := range dropped {
+		fmt.Printf("   %s\n", url)
+	}
+	fmt.Printf("Remove them from %s? (lines are commented out)\n", filePath)
+	fmt.Println("1 - No (default)")
+	fmt.Println("2 - Yes")
+	fmt.Print("Your choice: ")
+	fmt.Scanln(&choice)
+
+	if choice == "2" {
+		if err := commentOutBatchURLs(filePath, dropped, "no subtitles"); err != nil {
+			fmt.Printf("⚠ Error: %v\n", err)
+			return
+		}
+		fmt.Printf("✅ Removed %d video(s) from %s\n", len(dropped), filePath)
+	}
+}
+
+// downloadVideoItem downloads one video; with media-server options it also
+// places it in the library layout and writes the sidecar files. Returns the
+// name of the video file.
+func downloadVideoItem(ctx context.Context, url, folder string, subOptions subtitles.SubtitleOptions, libOptions library.Options) (string, error) {
+	fmt.Println("\n🔍 Fetching video info...")
 
-	for i, url := range urls {
-		fmt.Printf("\n🎬 Processing %d/%d: %s\n", i+1, len(urls), url)
-		fileName := video.GetVideoTitle(url)
-		video.DownloadVideoWithSubtitles(url, fileName, folder, subOptions)
-		if i < len(urls)-1 {
-			fmt.Println("⏳ Pause 2 seconds before next video...")
-			time.Sleep(2 * time.Second)
+	if !libOptions.Enabled() {
+		fileName, err := video.GetVideoTitle(ctx, url)
+		if err != nil {
+			return "", err
+		}
+		fmt.Printf("📁 Output file: %s\n", fileName)
+		downloadErr := video.DownloadVideoWithSubtitles(ctx, url, fileName, folder, subOptions)
+		if downloadErr != nil && !errors.Is(downloadErr, utils.ErrPostProcessing) {
+			return "", downloadErr
+		}
+		file, err := producedVideoFile(folder, fileName)
+		if err != nil {
+			return "", err
 		}
+		return file, downloadErr
 	}
 
-	fmt.Printf("\n🎉 Batch download completed! Processed: %d\n", len(urls))
-	utils.PlayBeepLong()
+	info, err := metadata.Fetch(ctx, url)
+	if err != nil {
+		return "", err
+	}
+
+	item := library.Place(folder, info, libOptions)
+	if err := os.MkdirAll(item.Folder, 0755); err != nil {
+		return "", fmt.Errorf("failed to create folder: %v", err)
+	}
+	fmt.Printf("📁 Output file: %s\n", filepath.Join(item.Folder, item.Filename))
+
+	// After failed post-processing the video is there and still gets its
+	// library sidecars; the error is returned with the file name
+	downloadErr := video.DownloadVideoWithSubtitles(ctx, url, item.Filename, item.Folder, subOptions)
+	if downloadErr != nil && !errors.Is(downloadErr, utils.ErrPostProcessing) {
+		return "", downloadErr
+	}
+	file, err := producedVideoFile(item.Folder, item.Filename)
+	if err != nil {
+		return "", err
+	}
+
+	if err := library.WriteSidecars(ctx, item, libOptions); err != nil {
+		fmt.Printf("⚠ %v\n", err)
+	}
+	return file, downloadErr
+}
+
+// producedVideoFile returns the name of the downloaded video file, whose
+// extension depends on the chosen format
+func producedVideoFile(folder, fileName string) (string, error) {
+	path, err := subtitles.FindVideoFile(folder, fileName)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(path), nil
+}
+
+// processVideoBatchFileWithSubtitles handles batch video downloads
+func processVideoBatchFileWithSubtitles(ctx context.Context, filePath string, folder string, subOptions subtitles.SubtitleOptions, libOptions library.Options) {
+	quality := video.SelectedVideoQuality
+	item := batchItem{
+		Mode:      batchVideo,
+		Folder:    folder,
+		Quality:   &quality,
+		Subtitles: subOptions,
+		Library:   libOptions,
+	}
+	if runBatch(ctx, batchItems(filePath, item)) {
+		utils.PlayBeepLong()
+	}
+}
+
+// processSubtitlesBatchFile downloads only subtitles for every URL in a file
+func processSubtitlesBatchFile(ctx context.Context, filePath string, folder string, subOptions subtitles.SubtitleOptions) {
+	item := batchItem{Mode: batchSubtitles, Folder: folder, Subtitles: subOptions}
+	if runBatch(ctx, batchItems(filePath, item)) {
+		utils.PlayBeepLong()
+	}
+}
+
+// printDownloadError shows a download error with advice for known causes
+func printDownloadError(err error) {
+	fmt.Printf("⚠ Error: %v\n", err)
+	if hint := utils.ErrorHint(err); hint != "" {
+		fmt.Printf("💡 %s\n", hint)
+	}
+}
+
+// interruptContext returns a context cancelled by the first Ctrl+C, which
+// stops the current download cleanly. A second Ctrl+C exits at once.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+		case <-ctx.Done():
+			return
+		}
+		fmt.Println("\n⏹ Stopping... press Ctrl+C again to exit at once")
+		cancel()
+
+		<-signals
+		fmt.Println("\n⛔ Forced exit")
+		os.Exit(130)
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
 }
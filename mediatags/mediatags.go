@@ -0,0 +1,198 @@
+package mediatags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"yt_downloader/metadata"
+	"yt_downloader/utils"
+)
+
+// configFile holds the tag mapping, next to links.txt
+const configFile = "metadata_tags.json"
+
+// Options controls metadata tag embedding
+type Options struct {
+	Embed  bool
+	Fields map[string]string // container tag -> template, e.g. "artist": "{channel}"
+}
+
+// DefaultFields maps the metadata model onto tags that MP4, MKV and MP3
+// players understand. The source URL goes to "comment" because the MP4
+// muxer drops tags it has no atom for.
+var DefaultFields = map[string]string{
+	"title":       "{title}",
+	"artist":      "{channel}",
+	"album":       "{playlist}",
+	"date":        "{upload_date}",
+	"description": "{description}",
+	"synopsis":    "{description}",
+	"comment":     "{webpage_url}",
+	"genre":       "{categories}",
+	"keywords":    "{tags}",
+}
+
+// placeholderRe matches {field} in tag templates
+var placeholderRe = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// LoadOptions reads the mapping from metadata_tags.json, creating the file
+// with DefaultFields when missing
+func LoadOptions() (Options, error) {
+	options := Options{Embed: true, Fields: DefaultFields}
+
+	data, err := os.ReadFile(configFile)
+	if os.IsNotExist(err) {
+		if data, err := json.MarshalIndent(DefaultFields, "", "  "); err == nil {
+			os.WriteFile(configFile, data, 0644)
+		}
+		return options, nil
+	}
+	if err != nil {
+		return options, fmt.Errorf("tag mapping read error: %v", err)
+	}
+
+	var fields map[string]string
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return options, fmt.Errorf("tag mapping parse error: %v", err)
+	}
+	options.Fields = fields
+	return options, nil
+}
+
+// Values exposes metadata fields usable as {placeholders}
+func Values(video *metadata.Video) map[string]string {
+	values := map[string]string{
+		"id":             video.ID,
+		"title":          video.Title,
+		"description":    video.Description,
+		"channel":        video.ChannelName(),
+		"channel_id":     video.ChannelID,
+		"channel_url":    video.ChannelURL,
+		"uploader":       video.Uploader,
+		"webpage_url":    video.WebpageURL,
+		"tags":           strings.Join(video.Tags, ", "),
+		"categories":     strings.Join(video.Categories, ", "),
+		"language":       video.Language,
+		"playlist":       video.PlaylistTitle,
+		"playlist_index": "",
+		"duration":       strconv.Itoa(int(video.Duration)),
+		"view_count":     strconv.FormatInt(video.ViewCount, 10),
+		"like_count":     strconv.FormatInt(video.LikeCount, 10),
+		"upload_date":    "",
+		"year":           "",
+	}
+	if video.PlaylistIndex > 0 {
+		values["playlist_index"] = strconv.Itoa(video.PlaylistIndex)
+	}
+	if uploaded, ok := video.Uploaded(); ok {
+		values["upload_date"] = uploaded.Format("2006-01-02")
+		values["year"] = strconv.Itoa(uploaded.Year())
+	}
+	if values["webpage_url"] == "" && video.ID != "" {
+		values["webpage_url"] = "https://www.youtube.com/watch?v=" + video.ID
+	}
+	return values
+}
+
+// Render fills the templates; tags that come out empty are left out
+func Render(fields map[string]string, video *metadata.Video) map[string]string {
+	values := Values(video)
+
+	tags := map[string]string{}
+	for tag, template := range fields {
+		value := placeholderRe.ReplaceAllStringFunc(template, func(m string) string {
+			return values[strings.Trim(m, "{}")]
+		})
+		if value = strings.TrimSpace(value); value != "" {
+			tags[tag] = value
+		}
+	}
+	return tags
+}
+
+// Embed writes tags into a media file, keeping all streams and chapters
+func Embed(ctx context.Context, mediaPath string, tags map[string]string) error {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tmpPath := strings.TrimSuffix(mediaPath, filepath.Ext(mediaPath)) + ".tags" + filepath.Ext(mediaPath)
+	args := []string{"-y", "-i", mediaPath, "-map", "0", "-c", "copy"}
+	for _, key := range keys {
+		args = append(args, "-metadata", key+"="+tags[key])
+	}
+	if strings.EqualFold(filepath.Ext(mediaPath), ".mp3") {
+		args = append(args, "-id3v2_version", "3")
+	}
+	args = append(args, tmpPath)
+
+	cmd := exec.CommandContext(ctx, utils.GetFFmpegBinary(), args...)
+	if output, err := utils.CombinedOutputOf(cmd); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("ffmpeg error: %v\n%s", err, strings.TrimSpace(string(output)))
+	}
+
+	if err := os.Rename(tmpPath, mediaPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace media file: %v", err)
+	}
+	return nil
+}
+
+// Process fetches metadata for a downloaded file and embeds the mapped tags
+func Process(ctx context.Context, url, mediaPath string, options Options) error {
+	if !options.Embed {
+		return nil
+	}
+
+	video, err := metadata.Fetch(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	fields := options.Fields
+	if fields == nil {
+		fields = DefaultFields
+	}
+	tags := Render(fields, video)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	if err := Embed(ctx, mediaPath, tags); err != nil {
+		return err
+	}
+	fmt.Printf("🏷 Metadata tags embedded: %d\n", len(tags))
+	return nil
+}
+
+// PromptOptions asks whether to embed tags
+func PromptOptions() Options {
+	var choice string
+	fmt.Println("\n🏷 === METADATA TAGS ===")
+	fmt.Println("Embed title, channel, date, description and source URL?")
+	fmt.Println("1 - No (default)")
+	fmt.Println("2 - Yes")
+	fmt.Printf("Field mapping is set in %s\n", configFile)
+	fmt.Print("Your choice: ")
+	fmt.Scanln(&choice)
+
+	if choice != "2" {
+		return Options{}
+	}
+
+	options, err := LoadOptions()
+	if err != nil {
+		fmt.Printf("⚠ %v, using defaults\n", err)
+	}
+	return options
+}
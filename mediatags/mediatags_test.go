@@ -0,0 +1,119 @@
+package mediatags
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+	"yt_downloader/metadata"
+)
+
+var video = &metadata.Video{
+	ID:            "abc",
+	Title:         "Talk",
+	Description:   "About things",
+	Channel:       "Chan",
+	Uploader:      "Uploader",
+	Tags:          []string{"go", "video"},
+	Categories:    []string{"Education"},
+	PlaylistTitle: "Talks",
+	PlaylistIndex: 3,
+	Duration:      61.7,
+	Timestamp:     time.Date(2024, 3, 7, 9, 0, 0, 0, time.UTC).Unix(),
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]string
+		want   map[string]string
+	}{
+		{
+			name:   "defaults",
+			fields: DefaultFields,
+			want: map[string]string{
+				"title":       "Talk",
+				"artist":      "Chan",
+				"album":       "Talks",
+				"date":        "2024-03-07",
+				"description": "About things",
+				"synopsis":    "About things",
+				"comment":     "https://www.youtube.com/watch?v=abc",
+				"genre":       "Education",
+				"keywords":    "go, video",
+			},
+		},
+		{
+			name: "several fields and literal text",
+			fields: map[string]string{
+				"title":  "{playlist_index}. {title} ({year})",
+				"artist": "{uploader} / {channel}",
+				"length": "{duration}s",
+			},
+			want: map[string]string{
+				"title":  "3. Talk (2024)",
+				"artist": "Uploader / Chan",
+				"length": "61s",
+			},
+		},
+		{
+			name:   "empty and unknown fields leave the tag out",
+			fields: map[string]string{"language": "{language}", "nonsense": "{no_such_field}", "kept": "{id}"},
+			want:   map[string]string{"kept": "abc"},
+		},
+		{
+			name:   "not a placeholder",
+			fields: map[string]string{"comment": "{Title} {title"},
+			want:   map[string]string{"comment": "{Title} {title"},
+		},
+		{
+			name:   "values are trimmed",
+			fields: map[string]string{"album": "  {playlist} {language}"},
+			want:   map[string]string{"album": "Talks"},
+		},
+	}
+	for _, tt := range tests {
+		if got := Render(tt.fields, video); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Render =\n%v\nwant\n%v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValuesWithoutOptionalData(t *testing.T) {
+	values := Values(&metadata.Video{ID: "xyz", WebpageURL: "https://youtu.be/xyz"})
+	if values["playlist_index"] != "" || values["upload_date"] != "" || values["year"] != "" {
+		t.Errorf("missing data must give empty values: %v", values)
+	}
+	if values["webpage_url"] != "https://youtu.be/xyz" {
+		t.Errorf("webpage_url = %q, the video's own URL wins", values["webpage_url"])
+	}
+}
+
+func TestLoadOptions(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	options, err := LoadOptions()
+	if err != nil || !options.Embed || !reflect.DeepEqual(options.Fields, DefaultFields) {
+		t.Fatalf("LoadOptions without a file = %+v, %v; want the defaults", options, err)
+	}
+	if _, err := os.Stat(configFile); err != nil {
+		t.Fatalf("default %s not written: %v", configFile, err)
+	}
+
+	// The file replaces the mapping: tags left out of it are not written
+	os.WriteFile(configFile, []byte(`{"title": "{title}", "artist": "{uploader}"}`), 0644)
+	options, err = LoadOptions()
+	want := map[string]string{"title": "{title}", "artist": "{uploader}"}
+	if err != nil || !reflect.DeepEqual(options.Fields, want) {
+		t.Errorf("LoadOptions = %+v, %v; want %v", options, err, want)
+	}
+	if got := Render(options.Fields, video); !reflect.DeepEqual(got, map[string]string{"title": "Talk", "artist": "Uploader"}) {
+		t.Errorf("Render with the loaded mapping = %v", got)
+	}
+
+	os.WriteFile(configFile, []byte(`{broken`), 0644)
+	options, err = LoadOptions()
+	if err == nil || !reflect.DeepEqual(options.Fields, DefaultFields) {
+		t.Errorf("LoadOptions of broken JSON = %+v, %v; want the defaults and an error", options, err)
+	}
+}
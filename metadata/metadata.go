@@ -0,0 +1,336 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"yt_downloader/utils"
+)
+
+// Live status values of Video.LiveStatus
+const (
+	NotLive    = "not_live"
+	IsLive     = "is_live"
+	IsUpcoming = "is_upcoming"
+	WasLive    = "was_live"
+	PostLive   = "post_live" // stream ended, VOD still processing
+)
+
+// Video is yt-dlp's info JSON for a single video
+type Video struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	FullTitle   string `json:"fulltitle"`
+	Description string `json:"description"`
+	WebpageURL  string `json:"webpage_url"`
+	Extractor   string `json:"extractor_key"`
+
+	Uploader    string `json:"uploader"`
+	UploaderID  string `json:"uploader_id"`
+	UploaderURL string `json:"uploader_url"`
+	Channel     string `json:"channel"`
+	ChannelID   string `json:"channel_id"`
+	ChannelURL  string `json:"channel_url"`
+
+	UploadDate  string  `json:"upload_date"` // YYYYMMDD
+	ReleaseDate string  `json:"release_date"`
+	Timestamp   int64   `json:"timestamp"` // unix seconds
+	Duration    float64 `json:"duration"`  // seconds
+
+	ViewCount    int64 `json:"view_count"`
+	LikeCount    int64 `json:"like_count"`
+	CommentCount int64 `json:"comment_count"`
+	AgeLimit     int   `json:"age_limit"`
+
+	Tags       []string `json:"tags"`
+	Categories []string `json:"categories"`
+	Language   string   `json:"language"`
+
+	Chapters   []Chapter   `json:"chapters"`
+	Thumbnail  string      `json:"thumbnail"` // best thumbnail URL
+	Thumbnails []Thumbnail `json:"thumbnails"`
+	Formats    []Format    `json:"formats"`
+
+	Subtitles         map[string][]SubtitleTrack `json:"subtitles"`
+	AutomaticCaptions map[string][]SubtitleTrack `json:"automatic_captions"`
+
+	LiveStatus string `json:"live_status"`
+	IsLive     bool   `json:"is_live"`
+	WasLive    bool   `json:"was_live"`
+
+	Playlist      string `json:"playlist"`
+	PlaylistID    string `json:"playlist_id"`
+	PlaylistTitle string `json:"playlist_title"`
+	PlaylistIndex int    `json:"playlist_index"`
+	PlaylistCount int    `json:"playlist_count"`
+
+	Raw json.RawMessage `json:"-"` // the original JSON, for .info.json sidecars
+}
+
+// Chapter is a named section of a video
+type Chapter struct {
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+	Title     string  `json:"title"`
+}
+
+// Thumbnail is one preview image size
+type Thumbnail struct {
+	ID         string `json:"id"`
+	URL        string `json:"url"`
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	Preference int    `json:"preference"`
+}
+
+// Format is one downloadable stream (video, audio or both)
+type Format struct {
+	FormatID   string `json:"format_id"`
+	FormatNote string `json:"format_note"`
+	Ext        string `json:"ext"`
+	Protocol   string `json:"protocol"`
+	URL        string `json:"url"`
+	Container  string `json:"container"`
+
+	Width        int     `json:"width"`
+	Height       int     `json:"height"`
+	FPS          float64 `json:"fps"`
+	Resolution   string  `json:"resolution"`
+	DynamicRange string  `json:"dynamic_range"` // SDR, HDR10, ...
+	VCodec       string  `json:"vcodec"`        // "none" for audio-only
+	ACodec       string  `json:"acodec"`        // "none" for video-only
+
+	TBR float64 `json:"tbr"` // total bitrate, kbit/s
+	VBR float64 `json:"vbr"`
+	ABR float64 `json:"abr"`
+	ASR int     `json:"asr"` // audio sample rate
+
+	AudioChannels      int     `json:"audio_channels"`
+	Language           string  `json:"language"`
+	LanguagePreference int     `json:"language_preference"`
+	Filesize           int64   `json:"filesize"`
+	FilesizeApprox     float64 `json:"filesize_approx"`
+}
+
+// SubtitleTrack is one format entry in yt-dlp's subtitles JSON
+type SubtitleTrack struct {
+	Ext  string `json:"ext"`
+	URL  string `json:"url"`
+	Name string `json:"name"`
+}
+
+// fetchCache keeps recent results: one download asks for the same
+// metadata from several post-processing steps
+var (
+	fetchCache   = map[string]cachedVideo{}
+	fetchCacheMu sync.Mutex
+)
+
+// cacheTTL is how long a fetched result is reused
+const cacheTTL = 10 * time.Minute
+
+type cachedVideo struct {
+	video   *Video
+	fetched time.Time
+}
+
+// Fetch retrieves complete metadata for a video with yt-dlp. Results are
+// reused for a few minutes. yt-dlp failures are classified, see
+// utils.ClassifyError. Cancelling ctx kills yt-dlp.
+func Fetch(ctx context.Context, url string) (*Video, error) {
+	fetchCacheMu.Lock()
+	cached, ok := fetchCache[url]
+	fetchCacheMu.Unlock()
+	if ok && time.Since(cached.fetched) < cacheTTL {
+		return cached.video, nil
+	}
+
+	video, err := fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	fetchCacheMu.Lock()
+	for key, entry := range fetchCache {
+		if time.Since(entry.fetched) >= cacheTTL {
+			delete(fetchCache, key)
+		}
+	}
+	fetchCache[url] = cachedVideo{video: video, fetched: time.Now()}
+	fetchCacheMu.Unlock()
+
+	return video, nil
+}
+
+// fetch runs yt-dlp --dump-json
+func fetch(ctx context.Context, url string) (*Video, error) {
+	ytPath := utils.GetYTDLPBinary()
+
+	// Ask yt-dlp for JSON metadata
+	cmd := exec.CommandContext(ctx, ytPath,
+		"--dump-json",         // выводить JSON
+		"--no-playlist",       // одно видео, даже если в URL есть список
+		"--no-warnings",       // без предупреждений
+		"--encoding", "utf-8", // кодировка
+		url,
+	)
+
+	cmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8")
+
+	output, err := utils.CommandOutputOf(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("metadata retrieval error: %w", utils.ClassifyError(err, nil))
+	}
+
+	return Parse(output)
+}
+
+// Parse decodes yt-dlp's info JSON
+func Parse(data []byte) (*Video, error) {
+	var video Video
+	if err := json.Unmarshal(data, &video); err != nil {
+		return nil, fmt.Errorf("JSON parse error: %v", err)
+	}
+	video.Raw = append(json.RawMessage(nil), data...)
+	return &video, nil
+}
+
+// Load reads a .info.json file written by yt-dlp or by WriteInfoJSON
+func Load(path string) (*Video, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("info JSON read error: %v", err)
+	}
+	return Parse(data)
+}
+
+// Uploaded returns the upload time, preferring the exact timestamp
+func (v *Video) Uploaded() (time.Time, bool) {
+	if v.Timestamp > 0 {
+		return time.Unix(v.Timestamp, 0).UTC(), true
+	}
+	date := v.UploadDate
+	if date == "" {
+		date = v.ReleaseDate
+	}
+	t, err := time.Parse("20060102", date)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// Length returns the duration as time.Duration
+func (v *Video) Length() time.Duration {
+	return time.Duration(v.Duration * float64(time.Second))
+}
+
+// ChannelName returns the channel, falling back to the uploader
+func (v *Video) ChannelName() string {
+	if v.Channel != "" {
+		return v.Channel
+	}
+	return v.Uploader
+}
+
+// Live reports whether the video is a live or upcoming stream
+func (v *Video) Live() bool {
+	return v.IsLive || v.LiveStatus == IsLive || v.LiveStatus == IsUpcoming
+}
+
+// BestThumbnail returns the largest thumbnail (by preference, then size)
+func (v *Video) BestThumbnail() (Thumbnail, bool) {
+	if len(v.Thumbnails) == 0 {
+		if v.Thumbnail != "" {
+			return Thumbnail{URL: v.Thumbnail}, true
+		}
+		return Thumbnail{}, false
+	}
+
+	thumbs := append([]Thumbnail(nil), v.Thumbnails...)
+	sort.SliceStable(thumbs, func(i, j int) bool {
+		if thumbs[i].Preference != thumbs[j].Preference {
+			return thumbs[i].Preference > thumbs[j].Preference
+		}
+		return thumbs[i].Width*thumbs[i].Height > thumbs[j].Width*thumbs[j].Height
+	})
+	return thumbs[0], true
+}
+
+// AudioFormats returns audio-only formats
+func (v *Video) AudioFormats() []Format {
+	var formats []Format
+	for _, format := range v.Formats {
+		if format.HasAudio() && !format.HasVideo() {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
+
+// VideoFormats returns formats carrying a video stream
+func (v *Video) VideoFormats() []Format {
+	var formats []Format
+	for _, format := range v.Formats {
+		if format.HasVideo() {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
+
+// AudioLanguages lists distinct audio track languages in format order
+func (v *Video) AudioLanguages() []string {
+	var languages []string
+	seen := map[string]bool{}
+	for _, format := range v.AudioFormats() {
+		if format.Language != "" && !seen[format.Language] {
+			seen[format.Language] = true
+			languages = append(languages, format.Language)
+		}
+	}
+	return languages
+}
+
+// HasVideo reports whether the format has a video stream
+func (f Format) HasVideo() bool {
+	return f.VCodec != "" && f.VCodec != "none"
+}
+
+// HasAudio reports whether the format has an audio stream
+func (f Format) HasAudio() bool {
+	return f.ACodec != "" && f.ACodec != "none"
+}
+
+// Size returns the exact or approximate file size in bytes
+func (f Format) Size() int64 {
+	if f.Filesize > 0 {
+		return f.Filesize
+	}
+	return int64(f.FilesizeApprox)
+}
+
+// String describes a format for menus and logs
+func (f Format) String() string {
+	var parts []string
+	if f.HasVideo() {
+		parts = append(parts, fmt.Sprintf("%dx%d", f.Width, f.Height))
+		if f.FPS > 0 {
+			parts = append(parts, fmt.Sprintf("%gfps", f.FPS))
+		}
+		parts = append(parts, f.VCodec)
+	}
+	if f.HasAudio() {
+		parts = append(parts, f.ACodec)
+		if f.Language != "" {
+			parts = append(parts, f.Language)
+		}
+	}
+	return fmt.Sprintf("%s [%s] %s", f.FormatID, f.Ext, strings.Join(parts, " "))
+}
@@ -0,0 +1,64 @@
+package queue
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// yt-dlp console lines the progress writer understands
+var (
+	progressRe    = regexp.MustCompile(`^\[download\]\s+([\d.]+)%(?:\s+of\s+~?\s*\S+)?(?:\s+at\s+(\S+))?(?:\s+ETA\s+(\S+))?`)
+	destinationRe = regexp.MustCompile(`^\[(?:download|ExtractAudio)\] Destination: (.+)$`)
+	mergerRe      = regexp.MustCompile(`^\[Merger\] Merging formats into "(.+)"$`)
+	alreadyRe     = regexp.MustCompile(`^\[download\] (.+) has already been downloaded`)
+)
+
+// progressWriter parses yt-dlp output into job progress
+type progressWriter struct {
+	mu      *sync.Mutex
+	job     *Job
+	partial string
+}
+
+// Write splits output on newlines and the carriage returns yt-dlp uses to
+// redraw its progress line
+func (w *progressWriter) Write(p []byte) (int, error) {
+	text := w.partial + string(p)
+	if text == "" {
+		return len(p), nil
+	}
+	lines := strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == '\r' })
+
+	w.partial = ""
+	if last := text[len(text)-1:]; len(lines) > 0 && last != "\n" && last != "\r" {
+		w.partial = lines[len(lines)-1]
+		lines = lines[:len(lines)-1]
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, line := range lines {
+		w.parse(strings.TrimSpace(line))
+	}
+	return len(p), nil
+}
+
+func (w *progressWriter) parse(line string) {
+	if m := progressRe.FindStringSubmatch(line); m != nil {
+		fmt.Sscanf(m[1], "%g", &w.job.Progress)
+		w.job.Speed, w.job.ETA = m[2], m[3]
+		return
+	}
+	for _, re := range []*regexp.Regexp{destinationRe, mergerRe, alreadyRe} {
+		if m := re.FindStringSubmatch(line); m != nil {
+			w.job.File = filepath.Base(m[1])
+			return
+		}
+	}
+	if strings.HasPrefix(line, "ERROR:") {
+		w.job.Error = strings.TrimSpace(strings.TrimPrefix(line, "ERROR:"))
+	}
+}
@@ -0,0 +1,562 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"yt_downloader/retry"
+	"yt_downloader/utils"
+)
+
+// Job states of Job.Status
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusPaused    = "paused"
+	StatusDone      = "done"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Job is one queued download. Request is whatever the owner of the queue
+// needs to run it, stored as JSON so the queue survives a restart.
+type Job struct {
+	ID        string          `json:"id"`
+	Request   json.RawMessage `json:"request"`
+	Priority  int             `json:"priority"` // higher runs first
+	Order     int64           `json:"order"`    // position among equal priorities
+	Status    string          `json:"status"`
+	Progress  float64         `json:"progress"` // percent of the stream being downloaded
+	Speed     string          `json:"speed,omitempty"`
+	ETA       string          `json:"eta,omitempty"`
+	File      string          `json:"file,omitempty"`
+	Attempts  int             `json:"attempts,omitempty"` // runs of the last start, retries included
+	Error     string          `json:"error,omitempty"`
+	ErrorKind string          `json:"error_kind,omitempty"` // class of a yt-dlp failure, see utils.ErrorKind
+	Created   time.Time       `json:"created"`
+	Started   *time.Time      `json:"started,omitempty"`
+	Finished  *time.Time      `json:"finished,omitempty"`
+
+	cancel context.CancelCauseFunc // stops the job while it runs
+}
+
+// Final reports whether the job has reached a final state
+func (j Job) Final() bool {
+	return j.Status == StatusDone || j.Status == StatusFailed || j.Status == StatusCancelled
+}
+
+// Decode unmarshals the job request
+func (j Job) Decode(v interface{}) error {
+	if err := json.Unmarshal(j.Request, v); err != nil {
+		return fmt.Errorf("job %s request parse error: %v", j.ID, err)
+	}
+	return nil
+}
+
+// RunFunc performs a job and returns the name of the produced file, also
+// along with an error when only post-processing failed. ctx is cancelled
+// when the job is cancelled or paused, with utils.ErrPaused as the cause
+// of a pause.
+type RunFunc func(ctx context.Context, job Job) (string, error)
+
+// state is what the queue keeps in its state file
+type state struct {
+	Paused bool   `json:"paused"`
+	NextID int    `json:"next_id"`
+	Jobs   []*Job `json:"jobs"`
+}
+
+// Queue runs jobs one at a time by priority. The download functions keep
+// their settings in package variables, so jobs never run in parallel.
+type Queue struct {
+	// Retry repeats jobs failing for transient reasons; the zero policy
+	// runs each job once
+	Retry retry.Policy
+
+	mu        sync.Mutex
+	changed   *sync.Cond
+	state     state
+	stateFile string
+	run       RunFunc
+	started   bool
+	stopped   bool // the context given to Start is done
+}
+
+// New creates a queue persisted in stateFile, loading jobs left there by a
+// previous run. Jobs that were running are queued again: yt-dlp continues
+// from its .part files.
+func New(stateFile string, run RunFunc) (*Queue, error) {
+	q := &Queue{stateFile: stateFile, run: run}
+	q.changed = sync.NewCond(&q.mu)
+
+	data, err := os.ReadFile(stateFile)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return q, fmt.Errorf("queue state read error: %v", err)
+	}
+	if err := json.Unmarshal(data, &q.state); err != nil {
+		return q, fmt.Errorf("queue state parse error: %v", err)
+	}
+
+	for _, job := range q.state.Jobs {
+		if job.Status == StatusRunning {
+			job.Status = StatusQueued
+			job.Speed, job.ETA = "", ""
+		}
+	}
+	return q, nil
+}
+
+// Start launches the worker. When ctx is done the running job is
+// cancelled and no more jobs start.
+func (q *Queue) Start(ctx context.Context) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.started {
+		return
+	}
+	q.started = true
+	context.AfterFunc(ctx, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.stopped = true
+		q.changed.Broadcast()
+	})
+	go q.worker(ctx)
+}
+
+// Add queues a request; v is stored as JSON
+func (q *Queue) Add(v interface{}, priority int) (Job, error) {
+	request, err := json.Marshal(v)
+	if err != nil {
+		return Job{}, fmt.Errorf("job request error: %v", err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.state.NextID++
+	job := &Job{
+		ID:       strconv.Itoa(q.state.NextID),
+		Request:  request,
+		Priority: priority,
+		Order:    int64(q.state.NextID),
+		Status:   StatusQueued,
+		Created:  time.Now(),
+	}
+	q.state.Jobs = append(q.state.Jobs, job)
+	q.update()
+	return *job, nil
+}
+
+// Jobs returns a snapshot in queue order: the running job, waiting jobs in
+// the order they will run, then finished jobs, newest first
+func (q *Queue) Jobs() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var jobs []Job
+	for _, job := range q.ordered() {
+		jobs = append(jobs, *job)
+	}
+
+	var finished []Job
+	for _, job := range q.state.Jobs {
+		if job.Final() {
+			finished = append(finished, *job)
+		}
+	}
+	sort.SliceStable(finished, func(i, j int) bool {
+		return finished[i].Finished.After(*finished[j].Finished)
+	})
+	return append(jobs, finished...)
+}
+
+// Job returns a snapshot of one job
+func (q *Queue) Job(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job := q.find(id)
+	if job == nil {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// Cancel stops a job for good; a running one has its yt-dlp process killed
+// and its partial files removed
+func (q *Queue) Cancel(id string) (Job, error) {
+	return q.change(id, func(job *Job) error {
+		switch job.Status {
+		case StatusQueued, StatusPaused:
+			finish(job, StatusCancelled)
+		case StatusRunning:
+			job.cancel(nil)
+		default:
+			return fmt.Errorf("job %s is already %s", job.ID, job.Status)
+		}
+		return nil
+	})
+}
+
+// Pause holds a job back; a running one is stopped and continues from its
+// partial download when resumed
+func (q *Queue) Pause(id string) (Job, error) {
+	return q.change(id, func(job *Job) error {
+		switch job.Status {
+		case StatusQueued:
+			job.Status = StatusPaused
+		case StatusRunning:
+			job.cancel(utils.ErrPaused)
+		default:
+			return fmt.Errorf("job %s is %s", job.ID, job.Status)
+		}
+		return nil
+	})
+}
+
+// Resume puts a paused job back in line
+func (q *Queue) Resume(id string) (Job, error) {
+	return q.change(id, func(job *Job) error {
+		if job.Status != StatusPaused {
+			return fmt.Errorf("job %s is %s", job.ID, job.Status)
+		}
+		job.Status = StatusQueued
+		return nil
+	})
+}
+
+// SetPriority changes the priority of a waiting job
+func (q *Queue) SetPriority(id string, priority int) (Job, error) {
+	return q.change(id, func(job *Job) error {
+		if !waiting(job) {
+			return fmt.Errorf("job %s is %s", job.ID, job.Status)
+		}
+		job.Priority = priority
+		return nil
+	})
+}
+
+// Move puts a waiting job at position among the waiting jobs, 0 being
+// next. The job takes the priority of its new neighbour so it stays there.
+func (q *Queue) Move(id string, position int) (Job, error) {
+	return q.change(id, func(job *Job) error {
+		if !waiting(job) {
+			return fmt.Errorf("job %s is %s", job.ID, job.Status)
+		}
+
+		var others []*Job
+		for _, other := range q.ordered() {
+			if waiting(other) && other != job {
+				others = append(others, other)
+			}
+		}
+		if position < 0 {
+			position = 0
+		}
+		if position > len(others) {
+			position = len(others)
+		}
+
+		switch {
+		case position < len(others):
+			job.Priority = others[position].Priority
+		case len(others) > 0:
+			job.Priority = others[len(others)-1].Priority
+		}
+
+		line := append(append(append([]*Job(nil), others[:position]...), job), others[position:]...)
+		for i, item := range line {
+			item.Order = int64(i)
+		}
+		return nil
+	})
+}
+
+// PauseAll stops starting new jobs; the running one finishes
+func (q *Queue) PauseAll() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.state.Paused = true
+	q.update()
+}
+
+// ResumeAll lets the queue start jobs again
+func (q *Queue) ResumeAll() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.state.Paused = false
+	q.update()
+}
+
+// Paused reports whether the whole queue is paused
+func (q *Queue) Paused() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.state.Paused
+}
+
+// Unfinished returns the number of jobs not yet done, failed or cancelled
+func (q *Queue) Unfinished() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	count := 0
+	for _, job := range q.state.Jobs {
+		if !job.Final() {
+			count++
+		}
+	}
+	return count
+}
+
+// Clear removes all jobs that are not running
+func (q *Queue) Clear() {
+	q.remove(func(job *Job) bool { return job.Status != StatusRunning })
+}
+
+// RemoveFinished forgets done, failed and cancelled jobs
+func (q *Queue) RemoveFinished() {
+	q.remove(func(job *Job) bool { return job.Final() })
+}
+
+// Wait blocks until nothing is running and nothing can start: the queue
+// is empty, or only paused jobs are left, or the queue is paused or stopped
+func (q *Queue) Wait() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for q.busy() {
+		q.changed.Wait()
+	}
+}
+
+// worker runs the next job whenever there is one, until ctx is done
+func (q *Queue) worker(ctx context.Context) {
+	for {
+		// Picking the job and marking it running is one critical section,
+		// so a job cancelled, paused or cleared meanwhile is never started
+		q.mu.Lock()
+		job := q.next()
+		for job == nil && !q.stopped {
+			q.changed.Wait()
+			job = q.next()
+		}
+		// ctx may be done before the stop callback has run
+		if job == nil || q.stopped || ctx.Err() != nil {
+			q.mu.Unlock()
+			return
+		}
+		jobCtx, cancel := context.WithCancelCause(ctx)
+		now := time.Now()
+		job.Status = StatusRunning
+		job.Started = &now
+		job.Progress, job.Error, job.ErrorKind, job.File = 0, "", "", ""
+		job.Attempts = 1
+		job.cancel = cancel
+		snapshot := *job
+		q.update()
+		q.mu.Unlock()
+
+		var file string
+		err := q.Retry.Do(jobCtx, func() error {
+			q.mu.Lock()
+			job.Error, job.ErrorKind = "", ""
+			q.mu.Unlock()
+
+			var err error
+			file, err = q.runJob(jobCtx, job, snapshot)
+			return err
+		}, func(retry int, delay time.Duration, err error) {
+			fmt.Printf("🔁 Retry %d/%d in %s...\n", retry, q.Retry.MaxAttempts-1, delay.Round(time.Second))
+
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			job.Attempts = retry + 1
+			job.Error = fmt.Sprintf("%v (retrying in %s)", err, delay.Round(time.Second))
+			job.ErrorKind = utils.ErrorKind(err)
+			job.Progress, job.Speed, job.ETA = 0, "", ""
+			q.update()
+		})
+
+		q.mu.Lock()
+		switch {
+		case errors.Is(context.Cause(jobCtx), utils.ErrPaused):
+			job.Status = StatusPaused
+			job.Speed, job.ETA = "", ""
+		case jobCtx.Err() != nil:
+			finish(job, StatusCancelled)
+		case err != nil:
+			job.File = file // set when only post-processing failed
+			job.Error = err.Error()
+			job.ErrorKind = utils.ErrorKind(err)
+			finish(job, StatusFailed)
+		default:
+			job.File = file
+			job.Error = ""
+			job.Progress = 100
+			finish(job, StatusDone)
+		}
+		cancel(nil)
+		job.cancel = nil
+		q.update()
+		q.mu.Unlock()
+	}
+}
+
+// runJob calls the run function with yt-dlp output parsed into progress
+func (q *Queue) runJob(ctx context.Context, job *Job, snapshot Job) (file string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic during download: %v", r)
+		}
+	}()
+
+	// Follow yt-dlp output while keeping it in the console
+	utils.CommandOutput = io.MultiWriter(os.Stdout, &progressWriter{mu: &q.mu, job: job})
+	utils.CommandErrors = io.MultiWriter(os.Stderr, &progressWriter{mu: &q.mu, job: job})
+	defer func() {
+		utils.CommandOutput, utils.CommandErrors = os.Stdout, os.Stderr
+	}()
+
+	return q.run(ctx, snapshot)
+}
+
+// next picks a queued job to run, or nil when there is none or the queue
+// is paused or stopped
+func (q *Queue) next() *Job {
+	if q.state.Paused || q.stopped {
+		return nil
+	}
+	for _, job := range q.ordered() {
+		if job.Status == StatusQueued {
+			return job
+		}
+	}
+	return nil
+}
+
+// ordered returns running and waiting jobs in run order
+func (q *Queue) ordered() []*Job {
+	var jobs []*Job
+	for _, job := range q.state.Jobs {
+		if job.Status == StatusRunning || waiting(job) {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		a, b := jobs[i], jobs[j]
+		if (a.Status == StatusRunning) != (b.Status == StatusRunning) {
+			return a.Status == StatusRunning
+		}
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		return a.Order < b.Order
+	})
+	return jobs
+}
+
+// busy reports whether a job is running or about to start
+func (q *Queue) busy() bool {
+	for _, job := range q.state.Jobs {
+		if job.Status == StatusRunning {
+			return true
+		}
+		if job.Status == StatusQueued && !q.state.Paused && !q.stopped {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *Queue) find(id string) *Job {
+	for _, job := range q.state.Jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// change applies edit to a job under the lock and saves the state
+func (q *Queue) change(id string, edit func(job *Job) error) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job := q.find(id)
+	if job == nil {
+		return Job{}, fmt.Errorf("job %s not found", id)
+	}
+	if err := edit(job); err != nil {
+		return *job, err
+	}
+	q.update()
+	return *job, nil
+}
+
+func (q *Queue) remove(drop func(job *Job) bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	kept := q.state.Jobs[:0]
+	for _, job := range q.state.Jobs {
+		if !drop(job) {
+			kept = append(kept, job)
+		}
+	}
+	q.state.Jobs = kept
+	q.update()
+}
+
+// update saves the state and wakes the worker and waiters; the lock must be held
+func (q *Queue) update() {
+	q.save()
+	q.changed.Broadcast()
+}
+
+// save writes the state file; progress is not saved, only status changes
+func (q *Queue) save() {
+	if q.stateFile == "" {
+		return
+	}
+
+	data, err := json.MarshalIndent(q.state, "", "  ")
+	if err != nil {
+		fmt.Println("⚠ Failed to save queue state:", err)
+		return
+	}
+	tmpPath := q.stateFile + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		fmt.Println("⚠ Failed to save queue state:", err)
+		return
+	}
+	if err := os.Rename(tmpPath, q.stateFile); err != nil {
+		os.Remove(tmpPath)
+		fmt.Println("⚠ Failed to save queue state:", err)
+	}
+}
+
+// waiting reports whether a job is queued or paused
+func waiting(job *Job) bool {
+	return job.Status == StatusQueued || job.Status == StatusPaused
+}
+
+// finish moves a job into a final state
+func finish(job *Job, status string) {
+	now := time.Now()
+	job.Status = status
+	job.Finished = &now
+	job.Speed, job.ETA = "", ""
+}
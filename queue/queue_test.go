@@ -0,0 +1,406 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+	"yt_downloader/utils"
+)
+
+// blockingRun reports started jobs and runs each until its ctx is done
+func blockingRun(started chan<- string) RunFunc {
+	return func(ctx context.Context, job Job) (string, error) {
+		started <- job.ID
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+}
+
+// recordingRun records the order of started jobs and finishes them at once
+func recordingRun(mu *sync.Mutex, order *[]string) RunFunc {
+	return func(ctx context.Context, job Job) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		*order = append(*order, job.ID)
+		return job.ID + ".mp4", nil
+	}
+}
+
+// waitStarted returns the ID of the next started job
+func waitStarted(t *testing.T, started <-chan string) string {
+	t.Helper()
+	select {
+	case id := <-started:
+		return id
+	case <-time.After(5 * time.Second):
+		t.Fatal("no job started")
+		return ""
+	}
+}
+
+// waitStatus polls until a job reaches status
+func waitStatus(t *testing.T, q *Queue, id, status string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, _ := q.Job(id)
+		if job.Status == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s is %s, want %s", id, job.Status, status)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func jobIDs(jobs []Job) []string {
+	var ids []string
+	for _, job := range jobs {
+		ids = append(ids, job.ID)
+	}
+	return ids
+}
+
+func addJobs(t *testing.T, q *Queue, priorities ...int) {
+	t.Helper()
+	for _, priority := range priorities {
+		if _, err := q.Add("request", priority); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPriorityOrder(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	q, err := New(filepath.Join(t.TempDir(), "queue.json"), recordingRun(&mu, &order))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addJobs(t, q, 0, 5, 0, 5, -1)
+
+	// Higher priority first, then in the order added
+	want := []string{"2", "4", "1", "3", "5"}
+	if got := jobIDs(q.Jobs()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Jobs() = %v, want %v", got, want)
+	}
+
+	if _, err := q.SetPriority("5", 10); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"5", "2", "4", "1", "3"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.Start(ctx)
+	q.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("run order = %v, want %v", order, want)
+	}
+	for _, job := range q.Jobs() {
+		if job.Status != StatusDone || job.File != job.ID+".mp4" || job.Progress != 100 {
+			t.Errorf("job %s = %s, file %q, progress %.0f", job.ID, job.Status, job.File, job.Progress)
+		}
+	}
+	if _, err := q.SetPriority("1", 3); err == nil {
+		t.Error("SetPriority of a finished job succeeded")
+	}
+}
+
+func TestMove(t *testing.T) {
+	q, err := New("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addJobs(t, q, 0, 0, 0, 2)
+
+	tests := []struct {
+		id       string
+		position int
+		want     []string
+	}{
+		{"3", 0, []string{"3", "4", "1", "2"}},  // takes the priority of job 4
+		{"3", 2, []string{"4", "1", "3", "2"}},  // between two priority 0 jobs
+		{"4", 99, []string{"1", "3", "2", "4"}}, // past the end: last
+		{"2", -5, []string{"2", "1", "3", "4"}}, // before the start: first
+		{"1", 1, []string{"2", "1", "3", "4"}},  // already there
+		{"4", 3, []string{"2", "1", "3", "4"}},  // last position is the end
+		{"4", 0, []string{"4", "2", "1", "3"}},
+	}
+	for _, tt := range tests {
+		if _, err := q.Move(tt.id, tt.position); err != nil {
+			t.Fatalf("Move(%s, %d): %v", tt.id, tt.position, err)
+		}
+		if got := jobIDs(q.Jobs()); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("after Move(%s, %d): %v, want %v", tt.id, tt.position, got, tt.want)
+		}
+	}
+
+	if _, err := q.Move("42", 0); err == nil {
+		t.Error("Move of a missing job succeeded")
+	}
+	if _, err := q.Cancel("3"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Move("3", 0); err == nil {
+		t.Error("Move of a cancelled job succeeded")
+	}
+
+	// Paused jobs keep their place in line
+	if _, err := q.Pause("1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Move("1", 0); err != nil {
+		t.Errorf("Move of a paused job: %v", err)
+	}
+	if got := jobIDs(q.Jobs())[:3]; !reflect.DeepEqual(got, []string{"1", "4", "2"}) {
+		t.Errorf("after moving the paused job: %v", got)
+	}
+}
+
+func TestPauseResumeJob(t *testing.T) {
+	started := make(chan string, 10)
+	var mu sync.Mutex
+	causes := map[string][]error{}
+	run := func(ctx context.Context, job Job) (string, error) {
+		started <- job.ID
+		<-ctx.Done()
+		mu.Lock()
+		causes[job.ID] = append(causes[job.ID], context.Cause(ctx))
+		mu.Unlock()
+		return "", ctx.Err()
+	}
+	q, err := New(filepath.Join(t.TempDir(), "queue.json"), run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addJobs(t, q, 0, 0)
+
+	// A queued job that is paused is skipped
+	if _, err := q.Pause("1"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.Start(ctx)
+	if id := waitStarted(t, started); id != "2" {
+		t.Fatalf("started job %s, want 2 while 1 is paused", id)
+	}
+
+	// Pausing the running job stops it without finishing it
+	if _, err := q.Pause("2"); err != nil {
+		t.Fatal(err)
+	}
+	waitStatus(t, q, "2", StatusPaused)
+
+	if _, err := q.Resume("1"); err != nil {
+		t.Fatal(err)
+	}
+	if id := waitStarted(t, started); id != "1" {
+		t.Fatalf("started job %s, want the resumed job 1", id)
+	}
+	if _, err := q.Resume("1"); err == nil {
+		t.Error("Resume of a running job succeeded")
+	}
+
+	if _, err := q.Resume("2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Cancel("1"); err != nil {
+		t.Fatal(err)
+	}
+	waitStatus(t, q, "1", StatusCancelled)
+	if id := waitStarted(t, started); id != "2" {
+		t.Fatalf("started job %s, want the resumed job 2", id)
+	}
+	if _, err := q.Cancel("2"); err != nil {
+		t.Fatal(err)
+	}
+	q.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(causes["2"]) != 2 || !errors.Is(causes["2"][0], utils.ErrPaused) || errors.Is(causes["2"][1], utils.ErrPaused) {
+		t.Errorf("job 2 causes = %v, want paused then cancelled", causes["2"])
+	}
+	if _, err := q.Pause("2"); err == nil {
+		t.Error("Pause of a cancelled job succeeded")
+	}
+}
+
+func TestPauseAll(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	q, err := New("", recordingRun(&mu, &order))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.PauseAll()
+	addJobs(t, q, 0, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.Start(ctx)
+	q.Wait() // returns at once: nothing can start
+	if !q.Paused() || q.Unfinished() != 2 {
+		t.Fatalf("paused queue ran jobs: paused %v, unfinished %d", q.Paused(), q.Unfinished())
+	}
+
+	q.ResumeAll()
+	q.Wait()
+	if q.Unfinished() != 0 {
+		t.Errorf("Unfinished() = %d after ResumeAll", q.Unfinished())
+	}
+}
+
+func TestFailedPostProcessingKeepsFile(t *testing.T) {
+	run := func(ctx context.Context, job Job) (string, error) {
+		return "video.mp4", utils.PostProcessingError(errors.New("thumbnail error: no image"))
+	}
+	q, err := New("", run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addJobs(t, q, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.Start(ctx)
+	q.Wait()
+
+	job, _ := q.Job("1")
+	if job.Status != StatusFailed || job.File != "video.mp4" || job.ErrorKind != "post-processing" {
+		t.Errorf("job = %s, file %q, kind %q; want failed with the file kept", job.Status, job.File, job.ErrorKind)
+	}
+}
+
+func TestStatePersistence(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "queue.json")
+	started := make(chan string, 10)
+	q, err := New(stateFile, blockingRun(started))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addJobs(t, q, 0, 3, 0)
+	if _, err := q.Pause("3"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Job 2 is running when the program goes away
+	ctx, cancel := context.WithCancel(context.Background())
+	q.Start(ctx)
+	if id := waitStarted(t, started); id != "2" {
+		t.Fatalf("started job %s, want 2", id)
+	}
+	q.PauseAll()
+
+	// A new queue from the same file, as after a restart; the first one
+	// still has job 2 running
+	restored, err := New(stateFile, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	q.Wait()
+
+	if !restored.Paused() {
+		t.Error("the queue pause was not restored")
+	}
+	want := map[string]string{"1": StatusQueued, "2": StatusQueued, "3": StatusPaused}
+	for id, status := range want {
+		job, ok := restored.Job(id)
+		if !ok || job.Status != status {
+			t.Errorf("restored job %s is %q, want %s (running jobs are queued again)", id, job.Status, status)
+		}
+	}
+	if got := jobIDs(restored.Jobs()); !reflect.DeepEqual(got, []string{"2", "1", "3"}) {
+		t.Errorf("restored order = %v, want [2 1 3]", got)
+	}
+	if job, _ := restored.Job("2"); job.Priority != 3 || string(job.Request) != `"request"` {
+		t.Errorf("restored job 2 = priority %d, request %s", job.Priority, job.Request)
+	}
+
+	// IDs continue after the restored ones
+	job, err := restored.Add("next", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.ID != "4" {
+		t.Errorf("new job ID = %s, want 4", job.ID)
+	}
+}
+
+func TestNewWithBrokenState(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "queue.json")
+	if err := writeFile(stateFile, "{not json"); err != nil {
+		t.Fatal(err)
+	}
+	q, err := New(stateFile, nil)
+	if err == nil {
+		t.Error("New with a broken state file succeeded")
+	}
+	if q == nil || q.Unfinished() != 0 {
+		t.Error("New must still return an empty usable queue")
+	}
+}
+
+func TestStopKeepsQueuedJobs(t *testing.T) {
+	started := make(chan string, 10)
+	q, err := New(filepath.Join(t.TempDir(), "queue.json"), blockingRun(started))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		if _, err := q.Add(name, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.Start(ctx)
+	select {
+	case id := <-started:
+		if id != "1" {
+			t.Fatalf("started job %s, want 1", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no job started")
+	}
+
+	cancel()
+	q.Wait()
+	// Give a wrongly running worker the chance to start another job
+	time.Sleep(50 * time.Millisecond)
+
+	want := map[string]string{"1": StatusCancelled, "2": StatusQueued, "3": StatusQueued}
+	for id, status := range want {
+		job, ok := q.Job(id)
+		if !ok {
+			t.Fatalf("job %s missing", id)
+		}
+		if job.Status != status {
+			t.Errorf("job %s is %s, want %s", id, job.Status, status)
+		}
+	}
+	if q.Unfinished() != 2 {
+		t.Errorf("Unfinished() = %d, want 2", q.Unfinished())
+	}
+	select {
+	case id := <-started:
+		t.Errorf("job %s started after the queue was stopped", id)
+	default:
+	}
+}
+
+func writeFile(path, data string) error {
+	return os.WriteFile(path, []byte(data), 0644)
+}
@@ -0,0 +1,107 @@
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"time"
+	"yt_downloader/utils"
+)
+
+// configFile holds the retry policy, next to links.txt
+const configFile = "retry_policy.json"
+
+// Policy says how often and how patiently a failed download is repeated.
+// yt-dlp's own --retries only covers single requests; this repeats the
+// whole item.
+type Policy struct {
+	MaxAttempts int     `json:"max_attempts"`       // 1 disables retrying
+	BaseDelay   float64 `json:"base_delay_seconds"` // before the second attempt
+	MaxDelay    float64 `json:"max_delay_seconds"`  // cap of the doubling delay
+	Jitter      float64 `json:"jitter"`             // 0..1, random share of the delay
+}
+
+// DefaultPolicy makes up to 3 attempts, waiting about 5s, then 10s
+var DefaultPolicy = Policy{
+	MaxAttempts: 3,
+	BaseDelay:   5,
+	MaxDelay:    120,
+	Jitter:      0.3,
+}
+
+// LoadPolicy reads retry_policy.json, creating the file with DefaultPolicy
+// when missing
+func LoadPolicy() (Policy, error) {
+	policy := DefaultPolicy
+
+	data, err := os.ReadFile(configFile)
+	if os.IsNotExist(err) {
+		if data, err := json.MarshalIndent(DefaultPolicy, "", "  "); err == nil {
+			os.WriteFile(configFile, data, 0644)
+		}
+		return policy, nil
+	}
+	if err != nil {
+		return policy, fmt.Errorf("retry policy read error: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return DefaultPolicy, fmt.Errorf("retry policy parse error: %v", err)
+	}
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	policy.Jitter = min(max(policy.Jitter, 0), 1)
+	return policy, nil
+}
+
+// Retryable reports whether err is likely to go away by itself: network
+// trouble, server errors and rate limiting. Private, removed and other
+// permanent failures are not retried, nor is failed post-processing of a
+// finished download, which would be repeated on an already processed file.
+func Retryable(err error) bool {
+	if errors.Is(err, utils.ErrPostProcessing) {
+		return false
+	}
+	return errors.Is(err, utils.ErrNetwork) || errors.Is(err, utils.ErrRateLimited)
+}
+
+// Delay returns the wait before the given retry, 1 being the first: the
+// base delay doubled each time up to MaxDelay (0 = no cap), spread by
+// Jitter so parallel clients don't come back at once
+func (p Policy) Delay(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	return time.Duration(delay * float64(time.Second))
+}
+
+// Do calls fn until it succeeds, fails with an error that is not
+// Retryable, runs out of attempts or ctx is cancelled. onRetry, if set,
+// is called before each wait.
+func (p Policy) Do(ctx context.Context, fn func() error, onRetry func(retry int, delay time.Duration, err error)) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || ctx.Err() != nil || attempt >= p.MaxAttempts || !Retryable(err) {
+			return err
+		}
+
+		delay := p.Delay(attempt)
+		if onRetry != nil {
+			onRetry(attempt, delay, err)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+	"yt_downloader/utils"
+)
+
+// fastPolicy keeps the tests quick: 1ms, 2ms, 4ms... capped at 5ms
+var fastPolicy = Policy{MaxAttempts: 4, BaseDelay: 0.001, MaxDelay: 0.005}
+
+var (
+	errNetwork   = fmt.Errorf("download error: %w", utils.ErrNetwork)
+	errPermanent = fmt.Errorf("download error: %w", utils.ErrPrivate)
+)
+
+func TestDelayGrowthAndCap(t *testing.T) {
+	policy := Policy{BaseDelay: 5, MaxDelay: 120}
+	want := []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second, 40 * time.Second, 80 * time.Second, 120 * time.Second, 120 * time.Second}
+	for i, w := range want {
+		if got := policy.Delay(i + 1); got != w {
+			t.Errorf("Delay(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+
+	// A base above the cap is capped too
+	if got := (Policy{BaseDelay: 10, MaxDelay: 3}).Delay(1); got != 3*time.Second {
+		t.Errorf("Delay with base over cap = %s, want 3s", got)
+	}
+	// No cap
+	if got := (Policy{BaseDelay: 1}).Delay(4); got != 8*time.Second {
+		t.Errorf("uncapped Delay(4) = %s, want 8s", got)
+	}
+}
+
+func TestDelayJitterBounds(t *testing.T) {
+	policy := Policy{BaseDelay: 10, MaxDelay: 100, Jitter: 0.3}
+	low, high := 7*time.Second, 13*time.Second
+	spread := false
+	for i := 0; i < 1000; i++ {
+		got := policy.Delay(1)
+		if got < low || got > high {
+			t.Fatalf("Delay(1) = %s, outside [%s, %s]", got, low, high)
+		}
+		if got != 10*time.Second {
+			spread = true
+		}
+	}
+	if !spread {
+		t.Error("jitter never changed the delay")
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errNetwork, true},
+		{fmt.Errorf("x: %w", utils.ErrRateLimited), true},
+		{errPermanent, false},
+		{errors.New("plain"), false},
+		{nil, false},
+		// The download is done, repeating it would post-process twice
+		{utils.PostProcessingError(errNetwork), false},
+	}
+	for _, tt := range tests {
+		if got := Retryable(tt.err); got != tt.want {
+			t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestDoRetriesUntilSuccess(t *testing.T) {
+	calls := 0
+	var retries []int
+	err := fastPolicy.Do(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return errNetwork
+		}
+		return nil
+	}, func(retry int, delay time.Duration, err error) {
+		retries = append(retries, retry)
+		if !errors.Is(err, utils.ErrNetwork) {
+			t.Errorf("onRetry got %v", err)
+		}
+		if delay > 5*time.Millisecond {
+			t.Errorf("delay %s over the cap", delay)
+		}
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Do = %v after %d calls, want nil after 3", err, calls)
+	}
+	if fmt.Sprint(retries) != "[1 2]" {
+		t.Errorf("onRetry calls = %v, want [1 2]", retries)
+	}
+}
+
+func TestDoGivesUpAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	err := fastPolicy.Do(context.Background(), func() error {
+		calls++
+		return errNetwork
+	}, nil)
+	if !errors.Is(err, utils.ErrNetwork) || calls != fastPolicy.MaxAttempts {
+		t.Errorf("Do = %v after %d calls, want the last error after %d", err, calls, fastPolicy.MaxAttempts)
+	}
+}
+
+func TestDoStopsOnPermanentError(t *testing.T) {
+	calls := 0
+	err := fastPolicy.Do(context.Background(), func() error {
+		calls++
+		if calls == 1 {
+			return errNetwork
+		}
+		return errPermanent
+	}, nil)
+	if !errors.Is(err, utils.ErrPrivate) || calls != 2 {
+		t.Errorf("Do = %v after %d calls, want the private error after 2", err, calls)
+	}
+}
+
+func TestDoSingleAttempt(t *testing.T) {
+	calls := 0
+	Policy{MaxAttempts: 1}.Do(context.Background(), func() error {
+		calls++
+		return errNetwork
+	}, nil)
+	if calls != 1 {
+		t.Errorf("MaxAttempts 1 made %d calls", calls)
+	}
+}
+
+func TestDoAbortsOnCancel(t *testing.T) {
+	// A long wait is cut short by the cancel
+	slow := Policy{MaxAttempts: 5, BaseDelay: 60, MaxDelay: 60}
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	start := time.Now()
+	err := slow.Do(ctx, func() error {
+		calls++
+		return errNetwork
+	}, func(int, time.Duration, error) {
+		cancel()
+	})
+	if !errors.Is(err, utils.ErrNetwork) || calls != 1 {
+		t.Errorf("Do = %v after %d calls, want the first error after 1", err, calls)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Do waited %s after the cancel", elapsed)
+	}
+
+	// fn cancelled meanwhile: no retry even for a retryable error
+	ctx, cancel = context.WithCancel(context.Background())
+	calls = 0
+	fastPolicy.Do(ctx, func() error {
+		calls++
+		cancel()
+		return errNetwork
+	}, nil)
+	if calls != 1 {
+		t.Errorf("retried %d times after the cancel", calls-1)
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	policy, err := LoadPolicy()
+	if err != nil || policy != DefaultPolicy {
+		t.Fatalf("LoadPolicy without a file = %+v, %v; want the default", policy, err)
+	}
+	if _, err := os.Stat(configFile); err != nil {
+		t.Errorf("default %s not written: %v", configFile, err)
+	}
+
+	os.WriteFile(configFile, []byte(`{"max_attempts": 0, "base_delay_seconds": 2, "jitter": 3}`), 0644)
+	policy, err = LoadPolicy()
+	want := Policy{MaxAttempts: 1, BaseDelay: 2, MaxDelay: DefaultPolicy.MaxDelay, Jitter: 1}
+	if err != nil || policy != want {
+		t.Errorf("LoadPolicy = %+v, %v; want %+v with values clamped", policy, err, want)
+	}
+
+	os.WriteFile(configFile, []byte(`{broken`), 0644)
+	if policy, err := LoadPolicy(); err == nil || policy != DefaultPolicy {
+		t.Errorf("LoadPolicy of broken JSON = %+v, %v; want the default and an error", policy, err)
+	}
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"yt_downloader/audio"
+	"yt_downloader/subformat"
+	"yt_downloader/subtitles"
+	"yt_downloader/utils"
+	"yt_downloader/video"
+)
+
+// Download modes of JobRequest.Mode
+const (
+	ModeAudio     = "audio"
+	ModeVideo     = "video"
+	ModeSubtitles = "subtitles"
+)
+
+// JobRequest is the body of POST /api/jobs
+type JobRequest struct {
+	URL       string           `json:"url"`
+	Mode      string           `json:"mode"`              // audio, video, subtitles (default video)
+	Quality   string           `json:"quality,omitempty"` // video resolution: 720p, 1080p, best, ...
+	Format    string           `json:"format,omitempty"`  // video container: mp4, webm, any
+	Bitrate   string           `json:"bitrate,omitempty"` // audio kbps: 32 ... 512
+	Subtitles *SubtitleRequest `json:"subtitles,omitempty"`
+	Folder    string           `json:"folder,omitempty"`   // default: the server's folder
+	Priority  int              `json:"priority,omitempty"` // higher runs first
+}
+
+// SubtitleRequest selects subtitles for a video or subtitles job
+type SubtitleRequest struct {
+	Languages string `json:"languages"`        // "uk>ru>en,de" or "all"
+	Format    string `json:"format,omitempty"` // srt, vtt, ass
+	Source    string `json:"source,omitempty"` // manual, auto, fallback
+	Embed     bool   `json:"embed,omitempty"`
+	Keep      *bool  `json:"keep,omitempty"` // keep sidecar files after embedding (default true)
+}
+
+// download is a validated request with its settings resolved
+type download struct {
+	request    JobRequest
+	quality    video.VideoQuality
+	subOptions subtitles.SubtitleOptions
+}
+
+// prepare validates a request and fills in defaults; audio jobs without a
+// bitrate get defaultBitrate
+func prepare(request JobRequest, defaultBitrate string) (*download, error) {
+	request.URL = strings.TrimSpace(request.URL)
+	if !utils.IsValidURL(request.URL) {
+		return nil, fmt.Errorf("invalid URL: %q", request.URL)
+	}
+
+	d := &download{request: request}
+
+	switch d.request.Mode {
+	case "":
+		d.request.Mode = ModeVideo
+	case ModeAudio, ModeVideo, ModeSubtitles:
+	default:
+		return nil, fmt.Errorf("unknown mode: %s", request.Mode)
+	}
+
+	switch d.request.Mode {
+	case ModeAudio:
+		if d.request.Bitrate == "" {
+			d.request.Bitrate = defaultBitrate
+		}
+		if !utils.ContainsString(audio.AudioBitrates, d.request.Bitrate) {
+			return nil, fmt.Errorf("unsupported bitrate: %s", request.Bitrate)
+		}
+	case ModeVideo:
+		d.quality = video.VideoQualities[0]
+		if d.request.Quality != "" {
+			quality, ok := video.FindVideoQuality(d.request.Quality, d.request.Format)
+			if !ok {
+				return nil, fmt.Errorf("unsupported quality: %s %s", request.Quality, request.Format)
+			}
+			d.quality = quality
+		}
+	case ModeSubtitles:
+		if d.request.Subtitles == nil {
+			return nil, fmt.Errorf("subtitles mode needs subtitle options")
+		}
+	}
+
+	if d.request.Subtitles != nil && d.request.Mode != ModeAudio {
+		subOptions, err := subtitleOptions(*d.request.Subtitles)
+		if err != nil {
+			return nil, err
+		}
+		d.subOptions = subOptions
+	}
+
+	if d.request.Folder == "" {
+		d.request.Folder, _ = os.Getwd()
+	}
+	return d, nil
+}
+
+// subtitleOptions turns a request into SubtitleOptions, like the
+// "subs backfill" flags
+func subtitleOptions(request SubtitleRequest) (subtitles.SubtitleOptions, error) {
+	options := subtitles.DefaultSubtitleOptions
+	options.DownloadSubtitles = true
+	options.EmbedSubtitles = request.Embed
+	if request.Format != "" {
+		options.SubtitleFormat = request.Format
+	}
+	if request.Source != "" {
+		options.CaptionSource = request.Source
+	}
+	if request.Keep != nil {
+		options.RemoveSidecars = !*request.Keep
+	}
+
+	if !subformat.IsSupported(options.SubtitleFormat) {
+		return options, fmt.Errorf("unsupported subtitle format: %s", options.SubtitleFormat)
+	}
+	switch options.CaptionSource {
+	case subtitles.CaptionsManual, subtitles.CaptionsAuto, subtitles.CaptionsFallback:
+	default:
+		return options, fmt.Errorf("unknown caption source: %s", options.CaptionSource)
+	}
+
+	if strings.TrimSpace(request.Languages) == "all" {
+		options.DownloadAll = true
+		return options, nil
+	}
+	languages, fallbacks, err := subtitles.ParseLanguageList(request.Languages)
+	if err != nil {
+		return options, err
+	}
+	options.Languages = languages
+	options.Fallbacks = fallbacks
+	options.DefaultLanguage = languages[0]
+	return options, nil
+}
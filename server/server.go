@@ -0,0 +1,337 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+	"yt_downloader/audio"
+	"yt_downloader/history"
+	"yt_downloader/queue"
+	"yt_downloader/retry"
+	"yt_downloader/subtitles"
+	"yt_downloader/utils"
+	"yt_downloader/video"
+)
+
+// stateFile keeps the server's queue across restarts
+const stateFile = "server_queue.json"
+
+// Server reports on and controls a download queue over a JSON API. Jobs
+// go through the same download functions as the console menus.
+type Server struct {
+	queue *queue.Queue
+
+	// defaultBitrate is audio.AudioBitrate when the server started; jobs
+	// get their own bitrate and never change the package default
+	defaultBitrate string
+}
+
+// New creates a server with the queue saved in server_queue.json and
+// starts it; the queue stops when ctx is done. Failed jobs are retried as
+// retry_policy.json says.
+func New(ctx context.Context) (*Server, error) {
+	s := &Server{defaultBitrate: audio.AudioBitrate}
+	q, err := queue.New(stateFile, func(ctx context.Context, job queue.Job) (string, error) {
+		file, err := s.runJob(ctx, job)
+		if err != nil && ctx.Err() == nil {
+			fmt.Printf("⚠ Job %s error: %v\n", job.ID, err)
+		}
+		return file, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := retry.LoadPolicy()
+	if err != nil {
+		fmt.Printf("⚠ %v, using the default retry policy\n", err)
+	}
+	q.Retry = policy
+	s.queue = q
+	q.Start(ctx)
+	return s, nil
+}
+
+// Run serves the API and web UI on addr until ctx is done or the listener
+// fails. On shutdown the running job is cancelled before Run returns.
+func Run(ctx context.Context, addr string) error {
+	s, err := New(ctx)
+	if err != nil {
+		return err
+	}
+
+	httpServer := &http.Server{Addr: addr, Handler: s.Handler()}
+	context.AfterFunc(ctx, func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	})
+
+	fmt.Printf("🌐 Web UI and API on http://%s\n", addr)
+	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	s.queue.Wait()
+	return nil
+}
+
+// Handler returns the API routes and the web UI:
+//
+//	POST   /api/jobs             submit a job (JobRequest)
+//	GET    /api/jobs             list jobs in queue order with progress
+//	GET    /api/jobs/{id}        one job
+//	DELETE /api/jobs/{id}        cancel a job, killing yt-dlp and removing partial files
+//	PATCH  /api/jobs/{id}        change {"priority": N} or move to {"position": N}
+//	POST   /api/jobs/{id}/pause  hold a job back, stopping it if running
+//	POST   /api/jobs/{id}/resume put a paused job back in line
+//	GET    /api/jobs/{id}/file   the downloaded file of a finished job
+//	GET    /api/queue            whether the queue is paused
+//	POST   /api/queue/pause      stop starting new jobs
+//	POST   /api/queue/resume     start jobs again
+//	GET    /api/history          download history, newest first (?url=, ?limit=)
+//	GET    /api/subtitles        subtitles available for ?url=
+//	GET    /api/options          qualities, bitrates and subtitle choices
+//	GET    /                     the web UI
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/jobs", s.handleSubmit)
+	mux.HandleFunc("GET /api/jobs", s.handleListJobs)
+	mux.HandleFunc("GET /api/jobs/{id}", s.handleGetJob)
+	mux.HandleFunc("DELETE /api/jobs/{id}", s.jobAction(s.queue.Cancel))
+	mux.HandleFunc("PATCH /api/jobs/{id}", s.handleUpdateJob)
+	mux.HandleFunc("POST /api/jobs/{id}/pause", s.jobAction(s.queue.Pause))
+	mux.HandleFunc("POST /api/jobs/{id}/resume", s.jobAction(s.queue.Resume))
+	mux.HandleFunc("GET /api/jobs/{id}/file", s.handleJobFile)
+	mux.HandleFunc("GET /api/queue", s.handleQueue)
+	mux.HandleFunc("POST /api/queue/pause", s.handleQueuePause)
+	mux.HandleFunc("POST /api/queue/resume", s.handleQueueResume)
+	mux.HandleFunc("GET /api/history", s.handleHistory)
+	mux.HandleFunc("GET /api/subtitles", s.handleSubtitles)
+	mux.HandleFunc("GET /api/options", s.handleOptions)
+	mux.Handle("GET /", webHandler())
+	return mux
+}
+
+// Submit validates a request and queues the job
+func (s *Server) Submit(request JobRequest) (queue.Job, error) {
+	d, err := prepare(request, s.defaultBitrate)
+	if err != nil {
+		return queue.Job{}, err
+	}
+	return s.queue.Add(d.request, d.request.Priority)
+}
+
+// runJob runs a queued request through the console download functions
+func (s *Server) runJob(ctx context.Context, job queue.Job) (string, error) {
+	var request JobRequest
+	if err := job.Decode(&request); err != nil {
+		return "", err
+	}
+	d, err := prepare(request, s.defaultBitrate)
+	if err != nil {
+		return "", err
+	}
+	request = d.request
+
+	fmt.Printf("\n🎬 Job %s: %s (%s)\n", job.ID, request.URL, request.Mode)
+	if err := os.MkdirAll(request.Folder, 0755); err != nil {
+		return "", fmt.Errorf("failed to create folder: %v", err)
+	}
+
+	switch request.Mode {
+	case ModeAudio:
+		fileName, err := audio.GetTitleFromURL(ctx, request.URL)
+		if err != nil {
+			return "", err
+		}
+		// After failed post-processing the file is there, the job still fails
+		err = audio.DownloadAudioWithBitrate(ctx, request.URL, fileName, request.Folder, request.Bitrate)
+		if err != nil && !errors.Is(err, utils.ErrPostProcessing) {
+			return "", err
+		}
+		recordHistory(request, fileName+".mp3")
+		return fileName + ".mp3", err
+
+	case ModeSubtitles:
+		fileName, err := video.GetVideoTitle(ctx, request.URL)
+		if err != nil {
+			return "", err
+		}
+		err = subtitles.DownloadSubtitlesOnly(ctx, request.URL, fileName, request.Folder, d.subOptions)
+		if err != nil && !errors.Is(err, utils.ErrPostProcessing) {
+			return "", err
+		}
+		return fileName, err
+
+	default:
+		fileName, err := video.GetVideoTitle(ctx, request.URL)
+		if err != nil {
+			return "", err
+		}
+		downloadErr := video.DownloadVideoWithQuality(ctx, request.URL, fileName, request.Folder, d.quality, d.subOptions)
+		if downloadErr != nil && !errors.Is(downloadErr, utils.ErrPostProcessing) {
+			return "", downloadErr
+		}
+
+		path, err := subtitles.FindVideoFile(request.Folder, fileName)
+		if err != nil {
+			return "", err
+		}
+		// The subtitle pipeline records its own history entry
+		if !d.subOptions.DownloadSubtitles {
+			recordHistory(request, filepath.Base(path))
+		}
+		return filepath.Base(path), downloadErr
+	}
+}
+
+// recordHistory saves a finished job to the download history
+func recordHistory(request JobRequest, fileName string) {
+	downloadTime := time.Now().Format("2006-01-02 15:04:05")
+	history.SaveToHistoryWithDetails(request.URL, fileName, downloadTime, map[string]string{
+		"mode":   request.Mode,
+		"source": "api",
+	})
+}
+
+// =================== Handlers ===================
+
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	var request JobRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON: %v", err))
+		return
+	}
+
+	job, err := s.Submit(request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, job)
+}
+
+func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	jobs := s.queue.Jobs()
+	if jobs == nil {
+		jobs = []queue.Job{}
+	}
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.queue.Job(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %s not found", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// jobAction wraps a queue operation on the job in the path
+func (s *Server) jobAction(action func(id string) (queue.Job, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if _, ok := s.queue.Job(id); !ok {
+			writeError(w, http.StatusNotFound, fmt.Errorf("job %s not found", id))
+			return
+		}
+
+		job, err := action(id)
+		if err != nil {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	}
+}
+
+func (s *Server) handleUpdateJob(w http.ResponseWriter, r *http.Request) {
+	var update struct {
+		Priority *int `json:"priority"`
+		Position *int `json:"position"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON: %v", err))
+		return
+	}
+
+	switch {
+	case update.Position != nil:
+		s.jobAction(func(id string) (queue.Job, error) {
+			return s.queue.Move(id, *update.Position)
+		})(w, r)
+	case update.Priority != nil:
+		s.jobAction(func(id string) (queue.Job, error) {
+			return s.queue.SetPriority(id, *update.Priority)
+		})(w, r)
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("need priority or position"))
+	}
+}
+
+func (s *Server) handleQueue(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]bool{"paused": s.queue.Paused()})
+}
+
+func (s *Server) handleQueuePause(w http.ResponseWriter, r *http.Request) {
+	s.queue.PauseAll()
+	s.handleQueue(w, r)
+}
+
+func (s *Server) handleQueueResume(w http.ResponseWriter, r *http.Request) {
+	s.queue.ResumeAll()
+	s.handleQueue(w, r)
+}
+
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	url := r.URL.Query().Get("url")
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	records := history.LoadHistory()
+	result := []map[string]string{}
+	for i := len(records) - 1; i >= 0; i-- {
+		if url != "" && records[i]["url"] != url {
+			continue
+		}
+		result = append(result, records[i])
+		if limit > 0 && len(result) == limit {
+			break
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleSubtitles(w http.ResponseWriter, r *http.Request) {
+	url := r.URL.Query().Get("url")
+	if !utils.IsValidURL(url) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid URL: %q", url))
+		return
+	}
+
+	list, err := subtitles.GetAvailableSubtitles(r.Context(), url)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	if list == nil {
+		list = []subtitles.SubtitleInfo{}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
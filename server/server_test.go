@@ -0,0 +1,255 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"yt_downloader/queue"
+	"yt_downloader/utils"
+)
+
+const testURL = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+
+func TestPrepare(t *testing.T) {
+	keep := false
+	tests := []struct {
+		name    string
+		request JobRequest
+		check   func(d *download) bool
+		wantErr bool
+	}{
+		{name: "video by default", request: JobRequest{URL: " " + testURL + " "}, check: func(d *download) bool {
+			return d.request.Mode == ModeVideo && d.request.URL == testURL && d.quality.Resolution == "720p" && !d.subOptions.DownloadSubtitles
+		}},
+		{name: "video quality and container", request: JobRequest{URL: testURL, Quality: "1080p", Format: "webm"}, check: func(d *download) bool {
+			return d.quality.Description == "1080p WebM"
+		}},
+		{name: "audio gets the default bitrate", request: JobRequest{URL: testURL, Mode: ModeAudio}, check: func(d *download) bool {
+			return d.request.Bitrate == "96"
+		}},
+		{name: "audio bitrate", request: JobRequest{URL: testURL, Mode: ModeAudio, Bitrate: "320"}, check: func(d *download) bool {
+			return d.request.Bitrate == "320"
+		}},
+		{name: "audio ignores subtitles", request: JobRequest{URL: testURL, Mode: ModeAudio, Subtitles: &SubtitleRequest{Languages: "!!"}}, check: func(d *download) bool {
+			return !d.subOptions.DownloadSubtitles
+		}},
+		{name: "subtitle options", request: JobRequest{URL: testURL, Mode: ModeSubtitles, Folder: "out", Subtitles: &SubtitleRequest{
+			Languages: "uk>ru,en", Format: "vtt", Source: "fallback", Embed: true, Keep: &keep,
+		}}, check: func(d *download) bool {
+			o := d.subOptions
+			return o.DownloadSubtitles && o.SubtitleFormat == "vtt" && o.CaptionSource == "fallback" && o.EmbedSubtitles && o.RemoveSidecars &&
+				reflect.DeepEqual(o.Languages, []string{"uk", "en"}) && reflect.DeepEqual(o.Fallbacks["uk"], []string{"ru"}) &&
+				o.DefaultLanguage == "uk" && d.request.Folder == "out"
+		}},
+		{name: "sidecars kept by default", request: JobRequest{URL: testURL, Subtitles: &SubtitleRequest{Languages: "all"}}, check: func(d *download) bool {
+			return d.subOptions.DownloadAll && !d.subOptions.RemoveSidecars
+		}},
+		{name: "invalid URL", request: JobRequest{URL: "not a url"}, wantErr: true},
+		{name: "unknown mode", request: JobRequest{URL: testURL, Mode: "podcast"}, wantErr: true},
+		{name: "unsupported bitrate", request: JobRequest{URL: testURL, Mode: ModeAudio, Bitrate: "100"}, wantErr: true},
+		{name: "unsupported quality", request: JobRequest{URL: testURL, Quality: "8k"}, wantErr: true},
+		{name: "subtitles mode without options", request: JobRequest{URL: testURL, Mode: ModeSubtitles}, wantErr: true},
+		{name: "subtitle format", request: JobRequest{URL: testURL, Subtitles: &SubtitleRequest{Languages: "en", Format: "sub"}}, wantErr: true},
+		{name: "caption source", request: JobRequest{URL: testURL, Subtitles: &SubtitleRequest{Languages: "en", Source: "any"}}, wantErr: true},
+		{name: "subtitle languages", request: JobRequest{URL: testURL, Subtitles: &SubtitleRequest{Languages: ""}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		d, err := prepare(tt.request, "96")
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: prepare succeeded, want an error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !tt.check(d) {
+			t.Errorf("%s: prepare = %+v", tt.name, d)
+		}
+		if d.request.Folder == "" {
+			t.Errorf("%s: no folder", tt.name)
+		}
+	}
+}
+
+// testServer returns a server whose queue is not started, so jobs stay
+// queued until a test runs them
+func testServer(t *testing.T, run queue.RunFunc) (*Server, *httptest.Server) {
+	t.Helper()
+	q, err := queue.New("", run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{queue: q, defaultBitrate: "128"}
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+// call sends a request and decodes the JSON response into out
+func call(t *testing.T, method, url, body string, out interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: %v", method, url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestSubmitAndList(t *testing.T) {
+	_, ts := testServer(t, nil)
+
+	var job queue.Job
+	if status := call(t, "POST", ts.URL+"/api/jobs", `{"url": "`+testURL+`", "mode": "audio"}`, &job); status != http.StatusCreated {
+		t.Fatalf("submit status %d", status)
+	}
+	var request JobRequest
+	if err := job.Decode(&request); err != nil || request.Bitrate != "128" || job.Status != queue.StatusQueued {
+		t.Errorf("submitted job = %+v, request %+v", job, request)
+	}
+
+	var failure map[string]string
+	if status := call(t, "POST", ts.URL+"/api/jobs", `{"url": "`+testURL+`", "bitrate": "1", "mode": "audio"}`, &failure); status != http.StatusBadRequest || failure["error"] == "" {
+		t.Errorf("invalid request: status %d, %v", status, failure)
+	}
+	if status := call(t, "POST", ts.URL+"/api/jobs", `{broken`, nil); status != http.StatusBadRequest {
+		t.Errorf("invalid JSON: status %d", status)
+	}
+
+	call(t, "POST", ts.URL+"/api/jobs", `{"url": "`+testURL+`", "priority": 5}`, nil)
+	var jobs []queue.Job
+	if status := call(t, "GET", ts.URL+"/api/jobs", "", &jobs); status != http.StatusOK || len(jobs) != 2 || jobs[0].ID != "2" {
+		t.Errorf("list: status %d, %+v", status, jobs)
+	}
+
+	if status := call(t, "GET", ts.URL+"/api/jobs/1", "", &job); status != http.StatusOK || job.ID != "1" {
+		t.Errorf("get: status %d, %+v", status, job)
+	}
+	if status := call(t, "GET", ts.URL+"/api/jobs/42", "", nil); status != http.StatusNotFound {
+		t.Errorf("get unknown: status %d", status)
+	}
+
+	var options Options
+	if call(t, "GET", ts.URL+"/api/options", "", &options); options.DefaultBitrate != "128" || len(options.Qualities) == 0 {
+		t.Errorf("options = %+v", options)
+	}
+}
+
+func TestCancelAndMove(t *testing.T) {
+	s, ts := testServer(t, nil)
+	for i := 0; i < 3; i++ {
+		if _, err := s.Submit(JobRequest{URL: testURL}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var job queue.Job
+	if status := call(t, "PATCH", ts.URL+"/api/jobs/3", `{"position": 0}`, &job); status != http.StatusOK {
+		t.Errorf("move: status %d", status)
+	}
+	var jobs []queue.Job
+	call(t, "GET", ts.URL+"/api/jobs", "", &jobs)
+	var order []string
+	for _, job := range jobs {
+		order = append(order, job.ID)
+	}
+	if !reflect.DeepEqual(order, []string{"3", "1", "2"}) {
+		t.Errorf("order after move = %v", order)
+	}
+
+	if status := call(t, "PATCH", ts.URL+"/api/jobs/1", `{"priority": 9}`, &job); status != http.StatusOK || job.Priority != 9 {
+		t.Errorf("priority: status %d, %+v", status, job)
+	}
+	if status := call(t, "PATCH", ts.URL+"/api/jobs/1", `{}`, nil); status != http.StatusBadRequest {
+		t.Errorf("empty update: status %d", status)
+	}
+	if status := call(t, "PATCH", ts.URL+"/api/jobs/42", `{"position": 0}`, nil); status != http.StatusNotFound {
+		t.Errorf("move unknown: status %d", status)
+	}
+
+	if status := call(t, "DELETE", ts.URL+"/api/jobs/2", "", &job); status != http.StatusOK || job.Status != queue.StatusCancelled {
+		t.Errorf("cancel: status %d, %+v", status, job)
+	}
+	if status := call(t, "DELETE", ts.URL+"/api/jobs/2", "", nil); status != http.StatusConflict {
+		t.Errorf("cancel twice: status %d", status)
+	}
+	if status := call(t, "PATCH", ts.URL+"/api/jobs/2", `{"position": 0}`, nil); status != http.StatusConflict {
+		t.Errorf("move a cancelled job: status %d", status)
+	}
+	if status := call(t, "DELETE", ts.URL+"/api/jobs/42", "", nil); status != http.StatusNotFound {
+		t.Errorf("cancel unknown: status %d", status)
+	}
+}
+
+func TestJobFile(t *testing.T) {
+	folder := t.TempDir()
+	if err := os.WriteFile(filepath.Join(folder, "Song.mp3"), []byte("audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Job 1 finishes, job 2 fails after the download, job 3 is a
+	// subtitles job and job 4 never runs
+	run := func(ctx context.Context, job queue.Job) (string, error) {
+		if job.ID == "2" {
+			return "Song.mp3", utils.PostProcessingError(io.ErrUnexpectedEOF)
+		}
+		return "Song.mp3", nil
+	}
+	s, ts := testServer(t, run)
+	for _, request := range []JobRequest{
+		{URL: testURL, Mode: ModeAudio, Folder: folder},
+		{URL: testURL, Mode: ModeAudio, Folder: folder},
+		{URL: testURL, Mode: ModeSubtitles, Folder: folder, Subtitles: &SubtitleRequest{Languages: "en"}},
+		{URL: testURL, Mode: ModeAudio, Folder: folder, Priority: -1},
+	} {
+		if _, err := s.Submit(request); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.queue.Pause("4"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.queue.Start(ctx)
+	s.queue.Wait()
+
+	resp, err := http.Get(ts.URL + "/api/jobs/1/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(data) != "audio" {
+		t.Errorf("finished job file: status %d, %q", resp.StatusCode, data)
+	}
+	if !strings.Contains(resp.Header.Get("Content-Disposition"), "Song.mp3") {
+		t.Errorf("Content-Disposition = %q", resp.Header.Get("Content-Disposition"))
+	}
+
+	for _, id := range []string{"2", "3", "4", "42"} {
+		if status := call(t, "GET", ts.URL+"/api/jobs/"+id+"/file", "", nil); status != http.StatusNotFound {
+			t.Errorf("job %s file: status %d, want 404", id, status)
+		}
+	}
+}
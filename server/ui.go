@@ -0,0 +1,86 @@
+package server
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"yt_downloader/audio"
+	"yt_downloader/queue"
+	"yt_downloader/subformat"
+	"yt_downloader/subtitles"
+	"yt_downloader/video"
+)
+
+// webFiles is the browser UI, compiled into the binary
+//
+//go:embed web
+var webFiles embed.FS
+
+// QualityOption is one entry of video.VideoQualities for the UI
+type QualityOption struct {
+	Resolution  string `json:"resolution"`
+	Format      string `json:"format"`
+	Description string `json:"description"`
+}
+
+// Options lists the choices the console menus offer
+type Options struct {
+	Qualities        []QualityOption `json:"qualities"`
+	Bitrates         []string        `json:"bitrates"`
+	DefaultBitrate   string          `json:"default_bitrate"`
+	SubtitleFormats  []string        `json:"subtitle_formats"`
+	CaptionSources   []string        `json:"caption_sources"`
+	DefaultLanguages string          `json:"default_languages"`
+}
+
+// webHandler serves the UI at /
+func webHandler() http.Handler {
+	root, err := fs.Sub(webFiles, "web")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServerFS(root)
+}
+
+func (s *Server) handleOptions(w http.ResponseWriter, r *http.Request) {
+	options := Options{
+		Bitrates:         audio.AudioBitrates,
+		DefaultBitrate:   s.defaultBitrate,
+		SubtitleFormats:  []string{subformat.FormatSRT, subformat.FormatVTT, subformat.FormatASS},
+		CaptionSources:   []string{subtitles.CaptionsManual, subtitles.CaptionsAuto, subtitles.CaptionsFallback},
+		DefaultLanguages: subtitles.FormatLanguageList(subtitles.DefaultSubtitleOptions.Languages, nil),
+	}
+	for _, quality := range video.VideoQualities {
+		options.Qualities = append(options.Qualities, QualityOption{
+			Resolution:  quality.Resolution,
+			Format:      quality.Format,
+			Description: quality.Description,
+		})
+	}
+	writeJSON(w, http.StatusOK, options)
+}
+
+// handleJobFile sends the file produced by a finished job
+func (s *Server) handleJobFile(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.queue.Job(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %s not found", r.PathValue("id")))
+		return
+	}
+
+	var request JobRequest
+	if err := job.Decode(&request); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if job.Status != queue.StatusDone || request.Mode == ModeSubtitles || job.File == "" {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %s has no file", job.ID))
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(job.File)))
+	http.ServeFile(w, r, filepath.Join(request.Folder, job.File))
+}
@@ -0,0 +1,288 @@
+// Web UI for the serve mode: a form for new jobs, a live job list and
+// the download history. Everything goes through the JSON API.
+
+const form = document.getElementById("job-form");
+const formError = document.getElementById("form-error");
+let historyRecords = [];
+const finishedJobs = new Set();
+
+async function api(method, path, body) {
+  const response = await fetch(path, {
+    method,
+    headers: body ? { "Content-Type": "application/json" } : {},
+    body: body ? JSON.stringify(body) : undefined,
+  });
+  const data = await response.json();
+  if (!response.ok) {
+    throw new Error(data.error || response.statusText);
+  }
+  return data;
+}
+
+function option(value, text, selected) {
+  const element = document.createElement("option");
+  element.value = value;
+  element.textContent = text;
+  element.selected = !!selected;
+  return element;
+}
+
+function cell(row, content, className) {
+  const td = row.insertCell();
+  if (content instanceof Node) {
+    td.appendChild(content);
+  } else {
+    td.textContent = content == null ? "" : content;
+  }
+  if (className) {
+    td.className = className;
+  }
+  return td;
+}
+
+// =================== Form ===================
+
+async function loadOptions() {
+  const options = await api("GET", "/api/options");
+
+  options.qualities.forEach((quality, i) => {
+    form.quality.appendChild(option(i, quality.description, i === 0));
+  });
+  form.quality.qualities = options.qualities;
+
+  options.bitrates.forEach((bitrate) => {
+    form.bitrate.appendChild(option(bitrate, bitrate + " kbps", bitrate === options.default_bitrate));
+  });
+  options.subtitle_formats.forEach((format) => {
+    form.sub_format.appendChild(option(format, format.toUpperCase()));
+  });
+  options.caption_sources.forEach((source) => {
+    form.source.appendChild(option(source, source));
+  });
+  form.languages.value = options.default_languages;
+}
+
+// updateForm shows only the pickers that apply to the chosen mode
+function updateForm() {
+  const mode = form.mode.value;
+  form.querySelectorAll("[data-mode]").forEach((element) => {
+    element.hidden = !element.dataset.mode.split(" ").includes(mode);
+  });
+  if (mode === "video" && !form.subtitles.checked) {
+    document.getElementById("subtitle-options").hidden = true;
+  }
+}
+
+form.mode.addEventListener("change", updateForm);
+form.subtitles.addEventListener("change", updateForm);
+
+form.addEventListener("submit", async (event) => {
+  event.preventDefault();
+  formError.textContent = "";
+
+  const request = {
+    url: form.url.value.trim(),
+    mode: form.mode.value,
+    folder: form.folder.value.trim(),
+    priority: parseInt(form.priority.value, 10),
+  };
+  if (request.mode === "video") {
+    const quality = form.quality.qualities[form.quality.value];
+    request.quality = quality.resolution;
+    request.format = quality.format;
+  }
+  if (request.mode === "audio") {
+    request.bitrate = form.bitrate.value;
+  }
+  if (request.mode === "subtitles" || (request.mode === "video" && form.subtitles.checked)) {
+    request.subtitles = {
+      languages: form.languages.value.trim(),
+      format: form.sub_format.value,
+      source: form.source.value,
+      embed: request.mode === "video" && form.embed.checked,
+    };
+  }
+
+  try {
+    await api("POST", "/api/jobs", request);
+    form.url.value = "";
+    refreshJobs();
+  } catch (error) {
+    formError.textContent = error.message;
+  }
+});
+
+// =================== Jobs ===================
+
+function progressCell(job) {
+  const wrapper = document.createElement("div");
+  const bar = document.createElement("div");
+  bar.className = "bar";
+  const fill = document.createElement("div");
+  fill.style.width = Math.min(job.progress, 100) + "%";
+  bar.appendChild(fill);
+  wrapper.appendChild(bar);
+
+  const text = document.createElement("small");
+  const parts = [job.progress.toFixed(1) + "%"];
+  if (job.speed) parts.push(job.speed);
+  if (job.eta) parts.push("ETA " + job.eta);
+  text.textContent = parts.join(" · ");
+  wrapper.appendChild(text);
+  return wrapper;
+}
+
+function button(text, action) {
+  const element = document.createElement("button");
+  element.type = "button";
+  element.className = "small";
+  element.textContent = text;
+  element.onclick = async () => {
+    try {
+      await action();
+    } catch (error) {
+      alert(error.message);
+    }
+    refreshJobs();
+  };
+  return element;
+}
+
+// actionCell offers the queue controls that apply to the job; position is
+// its place among waiting jobs
+function actionCell(job, position, waitingCount) {
+  const wrapper = document.createElement("div");
+  const path = "/api/jobs/" + job.id;
+
+  if (job.status === "queued" || job.status === "paused") {
+    if (position > 0) {
+      wrapper.appendChild(button("▲", () => api("PATCH", path, { position: position - 1 })));
+    }
+    if (position < waitingCount - 1) {
+      wrapper.appendChild(button("▼", () => api("PATCH", path, { position: position + 1 })));
+    }
+  }
+  if (job.status === "queued" || job.status === "running") {
+    wrapper.appendChild(button("Pause", () => api("POST", path + "/pause")));
+  }
+  if (job.status === "paused") {
+    wrapper.appendChild(button("Resume", () => api("POST", path + "/resume")));
+  }
+  if (job.status === "queued" || job.status === "running" || job.status === "paused") {
+    wrapper.appendChild(button("Cancel", () => api("DELETE", path)));
+  }
+
+  if (job.status === "done" && job.request.mode !== "subtitles" && job.file) {
+    const link = document.createElement("a");
+    link.href = path + "/file";
+    link.textContent = "⬇ " + job.file;
+    wrapper.appendChild(link);
+  }
+  if (job.error) {
+    const span = document.createElement("span");
+    span.className = "error";
+    span.textContent = job.error;
+    wrapper.appendChild(span);
+  }
+  return wrapper;
+}
+
+async function refreshJobs() {
+  let jobs;
+  try {
+    jobs = await api("GET", "/api/jobs");
+  } catch (error) {
+    return;
+  }
+
+  // Jobs come in queue order: running, waiting, then finished
+  const waiting = jobs.filter((job) => job.status === "queued" || job.status === "paused");
+  const tbody = document.querySelector("#jobs tbody");
+  tbody.innerHTML = "";
+  jobs.forEach((job) => {
+    const row = tbody.insertRow();
+    cell(row, job.id);
+    cell(row, job.request.url, "url").title = job.request.url;
+    cell(row, job.request.mode);
+    cell(row, job.status, "status-" + job.status);
+    cell(row, job.status === "running" || job.status === "done" ? progressCell(job) : "");
+    cell(row, actionCell(job, waiting.indexOf(job), waiting.length));
+  });
+  document.getElementById("no-jobs").hidden = jobs.length > 0;
+
+  // Finished jobs add history entries
+  const newlyDone = jobs.filter((job) => job.status === "done" && !finishedJobs.has(job.id));
+  newlyDone.forEach((job) => finishedJobs.add(job.id));
+  if (newlyDone.length > 0) {
+    loadHistory();
+  }
+}
+
+async function refreshQueue() {
+  let queue;
+  try {
+    queue = await api("GET", "/api/queue");
+  } catch (error) {
+    return;
+  }
+  document.getElementById("queue-state").textContent = queue.paused
+    ? "⏸ Queue paused: no new downloads start"
+    : "▶ Queue running";
+  const toggle = document.getElementById("queue-toggle");
+  toggle.textContent = queue.paused ? "Resume queue" : "Pause queue";
+  toggle.onclick = async () => {
+    await api("POST", queue.paused ? "/api/queue/resume" : "/api/queue/pause");
+    refreshQueue();
+  };
+}
+
+// =================== History ===================
+
+async function loadHistory() {
+  try {
+    historyRecords = await api("GET", "/api/history?limit=500");
+  } catch (error) {
+    return;
+  }
+  renderHistory();
+}
+
+function renderHistory() {
+  const filter = document.getElementById("history-filter").value.trim().toLowerCase();
+  const tbody = document.querySelector("#history tbody");
+  tbody.innerHTML = "";
+
+  historyRecords
+    .filter((record) => !filter ||
+      (record.file_name || "").toLowerCase().includes(filter) ||
+      (record.url || "").toLowerCase().includes(filter))
+    .forEach((record) => {
+      const row = tbody.insertRow();
+      cell(row, record.download_time);
+      cell(row, record.file_name);
+
+      const link = document.createElement("a");
+      link.href = record.url;
+      link.target = "_blank";
+      link.rel = "noopener";
+      link.textContent = record.url;
+      cell(row, link, "url");
+
+      const details = Object.keys(record)
+        .filter((key) => !["url", "file_name", "download_time"].includes(key))
+        .map((key) => key + ": " + record[key]);
+      cell(row, details.join("; "));
+    });
+}
+
+document.getElementById("history-filter").addEventListener("input", renderHistory);
+
+// =================== Start ===================
+
+loadOptions()
+  .catch((error) => { formError.textContent = error.message; })
+  .finally(updateForm);
+loadHistory();
+refreshQueue();
+refreshJobs();
+setInterval(refreshJobs, 1000);
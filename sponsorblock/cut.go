@@ -0,0 +1,256 @@
+package sponsorblock
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"yt_downloader/chapters"
+	"yt_downloader/subformat"
+	"yt_downloader/utils"
+)
+
+// Result is what Process did to a media file
+type Result struct {
+	Removed  []Segment     // in original timing
+	Marked   []Segment     // in original timing
+	Duration time.Duration // after removal
+}
+
+// Process fetches segments for a downloaded file, cuts out the Remove
+// categories and returns the segments to mark as chapters
+func Process(ctx context.Context, url, mediaPath string, options Options) (*Result, error) {
+	if !options.Enabled() {
+		return nil, nil
+	}
+
+	videoID := utils.ExtractVideoID(url)
+	if videoID == "" {
+		return nil, fmt.Errorf("SponsorBlock needs a YouTube video ID: %s", url)
+	}
+
+	categories := append(append([]string(nil), options.Profile.Remove...), options.Profile.Mark...)
+	segments, err := FetchSegments(ctx, options.APIURL, videoID, categories)
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := utils.ProbeDuration(ctx, mediaPath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Duration: duration}
+	for _, segment := range segments {
+		if segment.End > duration {
+			segment.End = duration
+		}
+		if segment.Start >= segment.End {
+			continue
+		}
+		if utils.ContainsString(options.Profile.Remove, segment.Category) {
+			result.Removed = append(result.Removed, segment)
+		} else {
+			result.Marked = append(result.Marked, segment)
+		}
+	}
+	result.Removed = mergeSegments(result.Removed)
+
+	if len(segments) == 0 {
+		fmt.Println("⏭ SponsorBlock: no segments")
+		return result, nil
+	}
+
+	if len(result.Removed) > 0 {
+		if err := Cut(ctx, mediaPath, result.Removed, duration); err != nil {
+			return nil, err
+		}
+		removed := totalLength(result.Removed)
+		result.Duration = duration - removed
+		fmt.Printf("✂ SponsorBlock: removed %d segment(s), %s\n", len(result.Removed), removed.Round(time.Second))
+	}
+	if len(result.Marked) > 0 {
+		fmt.Printf("⏭ SponsorBlock: %d segment(s) to mark as chapters\n", len(result.Marked))
+	}
+	return result, nil
+}
+
+// Cut removes segments from a media file using ffmpeg's concat demuxer with
+// stream copy, so video cuts land on the nearest keyframes. The file is
+// replaced only when ffmpeg finishes; a cancel leaves it untouched.
+func Cut(ctx context.Context, mediaPath string, removed []Segment, duration time.Duration) error {
+	base := strings.TrimSuffix(mediaPath, filepath.Ext(mediaPath))
+	listPath := base + ".ffconcat"
+	tmpPath := base + ".cut" + filepath.Ext(mediaPath)
+
+	absPath, err := filepath.Abs(mediaPath)
+	if err != nil {
+		return err
+	}
+	quoted := "'" + strings.ReplaceAll(filepath.ToSlash(absPath), "'", `'\''`) + "'"
+
+	var b strings.Builder
+	b.WriteString("ffconcat version 1.0\n")
+	for _, part := range keptParts(removed, duration) {
+		fmt.Fprintf(&b, "file %s\ninpoint %.3f\noutpoint %.3f\n", quoted, part.Start.Seconds(), part.End.Seconds())
+	}
+	if err := os.WriteFile(listPath, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("concat list write error: %v", err)
+	}
+	defer os.Remove(listPath)
+
+	cmd := exec.CommandContext(ctx, utils.GetFFmpegBinary(),
+		"-y", "-f", "concat", "-safe", "0", "-i", listPath,
+		"-map", "0", "-c", "copy",
+		tmpPath,
+	)
+	if output, err := utils.CombinedOutputOf(cmd); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("ffmpeg error: %v\n%s", err, strings.TrimSpace(string(output)))
+	}
+
+	if err := os.Rename(tmpPath, mediaPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace media file: %v", err)
+	}
+	return nil
+}
+
+// ChapterOptions turns on chapter embedding when there is something to mark
+func (r *Result) ChapterOptions(options chapters.Options) chapters.Options {
+	if r != nil && len(r.Marked) > 0 {
+		options.Embed = true
+	}
+	return options
+}
+
+// EditChapters adapts chapters to the processed file: times are shifted
+// past removed segments, and marked segments become chapters of their own
+func (r *Result) EditChapters(list []chapters.Chapter) []chapters.Chapter {
+	if r == nil || (len(r.Removed) == 0 && len(r.Marked) == 0) {
+		return list
+	}
+
+	var edited []chapters.Chapter
+	for _, ch := range list {
+		// An open chapter ends with the processed file
+		if ch.End == 0 {
+			ch.Start, ch.End = r.shift(ch.Start), r.Duration
+		} else {
+			ch.Start, ch.End = r.shift(ch.Start), r.shift(ch.End)
+		}
+		if ch.End > ch.Start {
+			edited = append(edited, ch)
+		}
+	}
+	if len(r.Marked) == 0 {
+		return edited
+	}
+
+	// Without chapters the marks still need a surrounding timeline
+	if len(edited) == 0 {
+		edited = []chapters.Chapter{{Start: 0, End: r.Duration, Title: "Content"}}
+	}
+
+	var marks []chapters.Chapter
+	for _, segment := range r.Marked {
+		mark := chapters.Chapter{Start: r.shift(segment.Start), End: r.shift(segment.End), Title: Categories[segment.Category]}
+		if mark.End > mark.Start {
+			marks = append(marks, mark)
+		}
+	}
+
+	// Split regular chapters around the marks
+	for _, mark := range marks {
+		var split []chapters.Chapter
+		for _, ch := range edited {
+			if mark.End <= ch.Start || mark.Start >= ch.End {
+				split = append(split, ch)
+				continue
+			}
+			if ch.Start < mark.Start {
+				split = append(split, chapters.Chapter{Start: ch.Start, End: mark.Start, Title: ch.Title})
+			}
+			if ch.End > mark.End {
+				split = append(split, chapters.Chapter{Start: mark.End, End: ch.End, Title: ch.Title})
+			}
+		}
+		edited = split
+	}
+
+	edited = append(edited, marks...)
+	sort.SliceStable(edited, func(i, j int) bool { return edited[i].Start < edited[j].Start })
+	return edited
+}
+
+// KeptRanges returns the parts of the original timeline left in the file,
+// for retiming subtitles with subformat's Keep, or nil when nothing was
+// removed
+func (r *Result) KeptRanges() []subformat.TimeRange {
+	if r == nil || len(r.Removed) == 0 {
+		return nil
+	}
+
+	var ranges []subformat.TimeRange
+	for _, part := range keptParts(r.Removed, r.Duration+totalLength(r.Removed)) {
+		ranges = append(ranges, subformat.TimeRange{Start: part.Start, End: part.End})
+	}
+	return ranges
+}
+
+// shift maps an original timestamp onto the file with segments removed
+func (r *Result) shift(t time.Duration) time.Duration {
+	var removed time.Duration
+	for _, segment := range r.Removed {
+		switch {
+		case t >= segment.End:
+			removed += segment.End - segment.Start
+		case t > segment.Start:
+			removed += t - segment.Start
+		}
+	}
+	return t - removed
+}
+
+// keptParts is the complement of removed within [0, duration]
+func keptParts(removed []Segment, duration time.Duration) []Segment {
+	var parts []Segment
+	var position time.Duration
+	for _, segment := range removed {
+		if segment.Start > position {
+			parts = append(parts, Segment{Start: position, End: segment.Start})
+		}
+		position = segment.End
+	}
+	if position < duration {
+		parts = append(parts, Segment{Start: position, End: duration})
+	}
+	return parts
+}
+
+// mergeSegments joins overlapping segments; input must be sorted by start
+func mergeSegments(segments []Segment) []Segment {
+	var merged []Segment
+	for _, segment := range segments {
+		if n := len(merged); n > 0 && segment.Start <= merged[n-1].End {
+			if segment.End > merged[n-1].End {
+				merged[n-1].End = segment.End
+			}
+			continue
+		}
+		merged = append(merged, segment)
+	}
+	return merged
+}
+
+func totalLength(segments []Segment) time.Duration {
+	var total time.Duration
+	for _, segment := range segments {
+		total += segment.End - segment.Start
+	}
+	return total
+}
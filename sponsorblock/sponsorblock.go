@@ -0,0 +1,232 @@
+package sponsorblock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the public SponsorBlock server
+const DefaultAPIURL = "https://sponsor.ajay.app"
+
+// configFile holds the API endpoint and profiles, next to links.txt
+const configFile = "sponsorblock.json"
+
+// Categories known to SponsorBlock and the chapter titles used when marking
+var Categories = map[string]string{
+	"sponsor":        "Sponsor",
+	"selfpromo":      "Self-promotion",
+	"interaction":    "Interaction reminder",
+	"intro":          "Intro",
+	"outro":          "Outro",
+	"preview":        "Preview",
+	"music_offtopic": "Non-music section",
+	"filler":         "Filler",
+}
+
+// Profile says which categories are cut out and which become chapters
+type Profile struct {
+	Remove []string `json:"remove"`
+	Mark   []string `json:"mark"`
+}
+
+// Config is the content of sponsorblock.json
+type Config struct {
+	APIURL   string             `json:"api_url"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// Options is the active SponsorBlock setup for a download
+type Options struct {
+	APIURL  string
+	Profile Profile
+}
+
+// Enabled reports whether any category is handled
+func (o Options) Enabled() bool {
+	return len(o.Profile.Remove) > 0 || len(o.Profile.Mark) > 0
+}
+
+// Segment is a skippable part of a video
+type Segment struct {
+	Start    time.Duration
+	End      time.Duration
+	Category string
+}
+
+// DefaultConfig is written to sponsorblock.json on first use
+var DefaultConfig = Config{
+	APIURL: DefaultAPIURL,
+	Profiles: map[string]Profile{
+		"lecture": {
+			Remove: []string{"sponsor", "selfpromo", "interaction", "intro", "outro"},
+		},
+		"podcast": {
+			Remove: []string{"sponsor", "selfpromo"},
+			Mark:   []string{"intro", "outro", "interaction"},
+		},
+		"mark-all": {
+			Mark: []string{"sponsor", "selfpromo", "interaction", "intro", "outro", "preview", "filler"},
+		},
+	},
+}
+
+// LoadConfig reads sponsorblock.json, creating it with defaults when
+// missing. SPONSORBLOCK_API_URL overrides the endpoint, e.g. for a mirror.
+// Profiles with unknown categories are left out of the returned config,
+// the error names them.
+func LoadConfig() (Config, error) {
+	config := DefaultConfig
+
+	data, err := os.ReadFile(configFile)
+	switch {
+	case os.IsNotExist(err):
+		if data, err := json.MarshalIndent(DefaultConfig, "", "  "); err == nil {
+			os.WriteFile(configFile, data, 0644)
+		}
+	case err != nil:
+		return config, fmt.Errorf("SponsorBlock config read error: %v", err)
+	default:
+		config = Config{}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return DefaultConfig, fmt.Errorf("SponsorBlock config parse error: %v", err)
+		}
+	}
+
+	if env := os.Getenv("SPONSORBLOCK_API_URL"); env != "" {
+		config.APIURL = env
+	}
+	if config.APIURL == "" {
+		config.APIURL = DefaultAPIURL
+	}
+
+	var errs []error
+	for name, profile := range config.Profiles {
+		for _, category := range append(append([]string(nil), profile.Remove...), profile.Mark...) {
+			if _, ok := Categories[category]; !ok {
+				errs = append(errs, fmt.Errorf("profile %s skipped: unknown category %q", name, category))
+				delete(config.Profiles, name)
+				break
+			}
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return config, errors.Join(errs...)
+}
+
+// apiClient talks to the SponsorBlock server
+var apiClient = &http.Client{Timeout: 15 * time.Second}
+
+// FetchSegments asks the API for skip segments of the given categories
+func FetchSegments(ctx context.Context, apiURL, videoID string, categories []string) ([]Segment, error) {
+	if len(categories) == 0 {
+		return nil, nil
+	}
+
+	categoriesJSON, _ := json.Marshal(categories)
+	query := url.Values{}
+	query.Set("videoID", videoID)
+	query.Set("categories", string(categoriesJSON))
+	query.Set("actionTypes", `["skip"]`)
+	endpoint := strings.TrimRight(apiURL, "/") + "/api/skipSegments?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("SponsorBlock request error: %v", err)
+	}
+	resp, err := apiClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("SponsorBlock request error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// 404 means the video has no segments
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("SponsorBlock API error: HTTP %d", resp.StatusCode)
+	}
+
+	var raw []struct {
+		Segment  []float64 `json:"segment"`
+		Category string    `json:"category"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("SponsorBlock response parse error: %v", err)
+	}
+
+	var segments []Segment
+	for _, item := range raw {
+		if len(item.Segment) != 2 || item.Segment[1] <= item.Segment[0] {
+			continue
+		}
+		segments = append(segments, Segment{
+			Start:    seconds(item.Segment[0]),
+			End:      seconds(item.Segment[1]),
+			Category: item.Category,
+		})
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].Start < segments[j].Start })
+	return segments, nil
+}
+
+// PromptOptions lets the user pick a profile from sponsorblock.json
+func PromptOptions() Options {
+	config, err := LoadConfig()
+	if err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Printf("⚠ %s\n", line)
+		}
+	}
+
+	names := make([]string, 0, len(config.Profiles))
+	for name := range config.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println("\n⏭ === SPONSORBLOCK ===")
+	fmt.Println("Handle sponsor/intro/outro segments?")
+	fmt.Println("1 - No (default)")
+	for i, name := range names {
+		fmt.Printf("%d - %s: %s\n", i+2, name, describeProfile(config.Profiles[name]))
+	}
+	fmt.Printf("Profiles and API endpoint are set in %s\n", configFile)
+	fmt.Print("Your choice: ")
+
+	var choice string
+	fmt.Scanln(&choice)
+
+	options := Options{APIURL: config.APIURL}
+	for i, name := range names {
+		if choice == fmt.Sprint(i+2) {
+			options.Profile = config.Profiles[name]
+			fmt.Printf("✅ SponsorBlock profile: %s\n", name)
+		}
+	}
+	return options
+}
+
+// describeProfile summarizes a profile for the menu
+func describeProfile(profile Profile) string {
+	var parts []string
+	if len(profile.Remove) > 0 {
+		parts = append(parts, "cut "+strings.Join(profile.Remove, ", "))
+	}
+	if len(profile.Mark) > 0 {
+		parts = append(parts, "mark "+strings.Join(profile.Mark, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,114 @@
+package sponsorblock
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"yt_downloader/chapters"
+	"yt_downloader/subformat"
+)
+
+func TestKeptRangesRetimeSubtitles(t *testing.T) {
+	result := &Result{
+		Removed: []Segment{
+			{Start: 10 * time.Second, End: 20 * time.Second},
+			{Start: 50 * time.Second, End: 60 * time.Second},
+		},
+		Duration: 40 * time.Second, // 60s with 20s removed
+	}
+
+	want := []subformat.TimeRange{
+		{Start: 0, End: 10 * time.Second},
+		{Start: 20 * time.Second, End: 50 * time.Second},
+	}
+	if got := result.KeptRanges(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("KeptRanges() = %v, want %v", got, want)
+	}
+
+	doc := &subformat.Document{Cues: []subformat.Cue{
+		{Start: 2 * time.Second, End: 4 * time.Second, Text: "before"},
+		{Start: 8 * time.Second, End: 12 * time.Second, Text: "into the cut"},
+		{Start: 12 * time.Second, End: 18 * time.Second, Text: "inside the cut"},
+		{Start: 25 * time.Second, End: 30 * time.Second, Text: "after"},
+		{Start: 55 * time.Second, End: 58 * time.Second, Text: "in the last cut"},
+	}}
+	doc.Keep(result.KeptRanges())
+
+	var got []string
+	for _, cue := range doc.Cues {
+		got = append(got, cue.Text+" "+cue.Start.String()+"-"+cue.End.String())
+	}
+	wantCues := []string{"before 2s-4s", "into the cut 8s-10s", "after 15s-20s"}
+	if !reflect.DeepEqual(got, wantCues) {
+		t.Errorf("cues after Keep = %v, want %v", got, wantCues)
+	}
+
+	// Every timestamp of the cut file must match the shifted chapter times
+	for _, at := range []time.Duration{5 * time.Second, 25 * time.Second, 45 * time.Second} {
+		single := &subformat.Document{Cues: []subformat.Cue{{Start: at, End: at + time.Second}}}
+		single.Keep(result.KeptRanges())
+		if len(single.Cues) != 1 || single.Cues[0].Start != result.shift(at) {
+			t.Errorf("Keep moved %s to %v, shift gives %s", at, single.Cues, result.shift(at))
+		}
+	}
+}
+
+func TestKeptRangesWithoutCuts(t *testing.T) {
+	var none *Result
+	if got := none.KeptRanges(); got != nil {
+		t.Errorf("nil result: KeptRanges() = %v, want nil", got)
+	}
+	marked := &Result{Marked: []Segment{{Start: time.Second, End: 2 * time.Second}}, Duration: time.Minute}
+	if got := marked.KeptRanges(); got != nil {
+		t.Errorf("marks only: KeptRanges() = %v, want nil", got)
+	}
+}
+
+func TestLoadConfigSkipsInvalidProfiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("SPONSORBLOCK_API_URL", "")
+
+	data := `{
+		"profiles": {
+			"good": {"remove": ["sponsor"], "mark": ["intro"]},
+			"typo": {"remove": ["sponsor", "sponser"]}
+		}
+	}`
+	if err := os.WriteFile(configFile, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig()
+	if err == nil || !strings.Contains(err.Error(), `profile typo skipped: unknown category "sponser"`) {
+		t.Errorf("LoadConfig() error = %v, want the typo profile reported", err)
+	}
+	if _, ok := config.Profiles["typo"]; ok {
+		t.Error("invalid profile was kept")
+	}
+	if _, ok := config.Profiles["good"]; !ok {
+		t.Error("valid profile was dropped")
+	}
+	if config.APIURL != DefaultAPIURL {
+		t.Errorf("APIURL = %q, want %q", config.APIURL, DefaultAPIURL)
+	}
+}
+
+func TestEditChaptersOpenEnd(t *testing.T) {
+	result := &Result{
+		Removed:  []Segment{{Start: 10 * time.Second, End: 20 * time.Second}},
+		Duration: 50 * time.Second,
+	}
+	edited := result.EditChapters([]chapters.Chapter{
+		{Start: 0, End: 30 * time.Second, Title: "One"},
+		{Start: 30 * time.Second, Title: "Two"}, // duration unknown when parsed
+	})
+	want := []chapters.Chapter{
+		{Start: 0, End: 20 * time.Second, Title: "One"},
+		{Start: 20 * time.Second, End: 50 * time.Second, Title: "Two"},
+	}
+	if !reflect.DeepEqual(edited, want) {
+		t.Errorf("EditChapters = %+v, want %+v", edited, want)
+	}
+}
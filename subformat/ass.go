@@ -0,0 +1,288 @@
+package subformat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Default script resolution and style for generated ASS files
+const (
+	defaultPlayResX = 1920
+	defaultPlayResY = 1080
+)
+
+// styleFields is the standard V4+ style format
+var styleFields = []string{
+	"Name", "Fontname", "Fontsize", "PrimaryColour", "SecondaryColour",
+	"OutlineColour", "BackColour", "Bold", "Italic", "Underline", "StrikeOut",
+	"ScaleX", "ScaleY", "Spacing", "Angle", "BorderStyle", "Outline", "Shadow",
+	"Alignment", "MarginL", "MarginR", "MarginV", "Encoding",
+}
+
+// styleDefaults fills style fields left empty
+var styleDefaults = map[string]string{
+	"Fontname":        "Arial",
+	"PrimaryColour":   "&H00FFFFFF",
+	"OutlineColour":   "&H00000000",
+	"BackColour":      "&H80000000",
+	"SecondaryColour": "&H000000FF",
+	"StrikeOut":       "0",
+	"ScaleX":          "100",
+	"ScaleY":          "100",
+	"Spacing":         "0",
+	"Angle":           "0",
+	"BorderStyle":     "1",
+	"Outline":         "3",
+	"Shadow":          "1",
+	"Encoding":        "1",
+}
+
+// DefaultStyle returns the style used when a document has none
+func DefaultStyle() Style {
+	return Style{
+		Name:          "Default",
+		FontName:      "Arial",
+		FontSize:      64,
+		PrimaryColour: "&H00FFFFFF",
+		OutlineColour: "&H00000000",
+		BackColour:    "&H80000000",
+		Alignment:     2,
+		MarginL:       40,
+		MarginR:       40,
+		MarginV:       40,
+	}
+}
+
+// parseASS parses Advanced SubStation Alpha (and SSA) text
+func parseASS(text string) (*Document, error) {
+	doc := &Document{}
+	var section string
+	var styleFormat, eventFormat []string
+
+	for n, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(line)
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch section {
+		case "[script info]":
+			switch key {
+			case "PlayResX":
+				doc.PlayResX, _ = strconv.Atoi(value)
+			case "PlayResY":
+				doc.PlayResY, _ = strconv.Atoi(value)
+			}
+
+		case "[v4+ styles]", "[v4 styles]":
+			switch key {
+			case "Format":
+				styleFormat = splitFormat(value)
+			case "Style":
+				if styleFormat == nil {
+					styleFormat = styleFields
+				}
+				doc.Styles = append(doc.Styles, parseStyle(styleFormat, value))
+			}
+
+		case "[events]":
+			switch key {
+			case "Format":
+				eventFormat = splitFormat(value)
+			case "Dialogue":
+				if eventFormat == nil {
+					return nil, fmt.Errorf("line %d: Dialogue before Format", n+1)
+				}
+				cue, err := parseDialogue(eventFormat, value, doc)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %v", n+1, err)
+				}
+				doc.Cues = append(doc.Cues, cue)
+			}
+		}
+	}
+
+	if doc.PlayResX == 0 || doc.PlayResY == 0 {
+		// ASS renderers assume 384x288 when the script doesn't say
+		doc.PlayResX, doc.PlayResY = 384, 288
+	}
+	return doc, nil
+}
+
+// splitFormat splits a "Format:" line into field names
+func splitFormat(value string) []string {
+	fields := strings.Split(value, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+// parseStyle reads a "Style:" line according to its format
+func parseStyle(format []string, value string) Style {
+	values := strings.SplitN(value, ",", len(format))
+	style := Style{Raw: map[string]string{}}
+
+	for i, field := range format {
+		if i >= len(values) {
+			break
+		}
+		v := strings.TrimSpace(values[i])
+		style.Raw[field] = v
+
+		switch field {
+		case "Name":
+			style.Name = v
+		case "Fontname":
+			style.FontName = v
+		case "Fontsize":
+			style.FontSize, _ = strconv.ParseFloat(v, 64)
+		case "PrimaryColour":
+			style.PrimaryColour = v
+		case "OutlineColour":
+			style.OutlineColour = v
+		case "BackColour":
+			style.BackColour = v
+		case "Bold":
+			style.Bold = v != "0"
+		case "Italic":
+			style.Italic = v != "0"
+		case "Underline":
+			style.Underline = v != "0"
+		case "Alignment":
+			style.Alignment, _ = strconv.Atoi(v)
+		case "MarginL":
+			style.MarginL, _ = strconv.Atoi(v)
+		case "MarginR":
+			style.MarginR, _ = strconv.Atoi(v)
+		case "MarginV":
+			style.MarginV, _ = strconv.Atoi(v)
+		}
+	}
+
+	return style
+}
+
+// parseDialogue reads a "Dialogue:" line according to the events format
+func parseDialogue(format []string, value string, doc *Document) (Cue, error) {
+	values := strings.SplitN(value, ",", len(format))
+	if len(values) < len(format) {
+		return Cue{}, fmt.Errorf("dialogue has %d fields, want %d", len(values), len(format))
+	}
+
+	var cue Cue
+	var err error
+	for i, field := range format {
+		v := values[i]
+		switch field {
+		case "Start":
+			if cue.Start, err = ParseTimestamp(v); err != nil {
+				return Cue{}, err
+			}
+		case "End":
+			if cue.End, err = ParseTimestamp(v); err != nil {
+				return Cue{}, err
+			}
+		case "Style":
+			cue.Style = strings.TrimSpace(v)
+		case "Text":
+			cue.Text, cue.Position = fromASSText(v, doc.PlayResX, doc.PlayResY)
+		}
+	}
+
+	return cue, nil
+}
+
+// encodeASS renders the document as ASS
+func encodeASS(d *Document) []byte {
+	playResX, playResY := d.PlayResX, d.PlayResY
+	if playResX == 0 || playResY == 0 {
+		playResX, playResY = defaultPlayResX, defaultPlayResY
+	}
+
+	styles := d.Styles
+	if len(styles) == 0 {
+		styles = []Style{DefaultStyle()}
+	}
+
+	var b strings.Builder
+	b.WriteString("[Script Info]\n")
+	b.WriteString("ScriptType: v4.00+\n")
+	b.WriteString("WrapStyle: 0\n")
+	b.WriteString("ScaledBorderAndShadow: yes\n")
+	fmt.Fprintf(&b, "PlayResX: %d\nPlayResY: %d\n\n", playResX, playResY)
+
+	b.WriteString("[V4+ Styles]\n")
+	b.WriteString("Format: " + strings.Join(styleFields, ", ") + "\n")
+	for _, style := range styles {
+		b.WriteString("Style: " + formatStyle(style) + "\n")
+	}
+
+	b.WriteString("\n[Events]\n")
+	b.WriteString("Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n")
+	for _, cue := range d.Cues {
+		style := cue.Style
+		if _, ok := d.StyleByName(style); !ok {
+			style = styles[0].Name
+		}
+
+		fmt.Fprintf(&b, "Dialogue: 0,%s,%s,%s,,0,0,0,,%s\n",
+			formatASSTime(cue.Start), formatASSTime(cue.End), style,
+			toASSText(cue.Text, cue.Position, playResX, playResY))
+	}
+
+	return []byte(b.String())
+}
+
+// formatStyle renders a "Style:" line body in styleFields order
+func formatStyle(style Style) string {
+	modeled := map[string]string{
+		"Name":          style.Name,
+		"Fontname":      style.FontName,
+		"Fontsize":      strconv.FormatFloat(style.FontSize, 'f', -1, 64),
+		"PrimaryColour": style.PrimaryColour,
+		"OutlineColour": style.OutlineColour,
+		"BackColour":    style.BackColour,
+		"Bold":          assBool(style.Bold),
+		"Italic":        assBool(style.Italic),
+		"Underline":     assBool(style.Underline),
+		"Alignment":     strconv.Itoa(style.Alignment),
+		"MarginL":       strconv.Itoa(style.MarginL),
+		"MarginR":       strconv.Itoa(style.MarginR),
+		"MarginV":       strconv.Itoa(style.MarginV),
+	}
+
+	values := make([]string, len(styleFields))
+	for i, field := range styleFields {
+		switch {
+		case modeled[field] != "":
+			values[i] = modeled[field]
+		case style.Raw[field] != "":
+			values[i] = style.Raw[field]
+		default:
+			values[i] = styleDefaults[field]
+		}
+	}
+	return strings.Join(values, ",")
+}
+
+// assBool renders ASS booleans (-1 = true)
+func assBool(v bool) string {
+	if v {
+		return "-1"
+	}
+	return "0"
+}
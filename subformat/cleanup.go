@@ -0,0 +1,289 @@
+package subformat
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CleanupOptions configures the cleanup pipeline. Zero values disable a step.
+type CleanupOptions struct {
+	StripTags           bool          // inline HTML/VTT styling and karaoke timings
+	StripAnnotations    bool          // [Music], (applause), ♪
+	StripSpeakerMarkers bool          // ">>", leading "- ", "NAME:"
+	CollapseRolling     bool          // lines repeated from the previous cue (YouTube auto captions)
+	FixOverlaps         bool          // cut cues that run into the next one
+	MergeShorterThan    time.Duration // merge cues shorter than this into a neighbour
+	MinDuration         time.Duration // extend short cues, without overlapping the next
+	MaxDuration         time.Duration // trim cues that stay on screen too long
+	MaxLineLength       int           // re-wrap lines to this many characters
+	MaxLines            int           // lines per cue when re-wrapping (default 2)
+}
+
+// DefaultCleanupOptions is a sensible preset for downloaded captions
+var DefaultCleanupOptions = CleanupOptions{
+	StripTags:           true,
+	StripAnnotations:    true,
+	StripSpeakerMarkers: true,
+	CollapseRolling:     true,
+	FixOverlaps:         true,
+	MergeShorterThan:    700 * time.Millisecond,
+	MinDuration:         time.Second,
+	MaxDuration:         7 * time.Second,
+	MaxLineLength:       42,
+	MaxLines:            2,
+}
+
+// Enabled reports whether any cleanup step is switched on
+func (o CleanupOptions) Enabled() bool {
+	return o != CleanupOptions{}
+}
+
+var (
+	bracketAnnotationRe = regexp.MustCompile(`\[[^\]]*\]`)
+	parenLineRe         = regexp.MustCompile(`^\([^)]*\)$`)
+	musicNoteRe         = regexp.MustCompile(`[♪♫]+`)
+	speakerArrowRe      = regexp.MustCompile(`^>>+\s*`)
+	speakerDashRe       = regexp.MustCompile(`^-\s+`)
+	speakerNameRe       = regexp.MustCompile(`^[A-ZА-ЯЁ][A-ZА-ЯЁ0-9 .'-]{0,30}:\s+`)
+)
+
+// Cleanup runs the configured cleanup steps in order
+func (d *Document) Cleanup(options CleanupOptions) {
+	sortCues(d.Cues)
+
+	for i := range d.Cues {
+		text := d.Cues[i].Text
+		if options.StripTags {
+			text = PlainText(text)
+		}
+		if options.StripAnnotations {
+			text = stripAnnotations(text)
+		}
+		if options.StripSpeakerMarkers {
+			text = stripSpeakerMarkers(text)
+		}
+		d.Cues[i].Text = normalizeLines(text)
+	}
+
+	if options.CollapseRolling {
+		d.collapseRolling()
+	}
+	d.dropEmpty()
+
+	if options.FixOverlaps {
+		d.fixOverlaps()
+	}
+	if options.MergeShorterThan > 0 {
+		d.mergeShort(options)
+	}
+	if options.MinDuration > 0 || options.MaxDuration > 0 {
+		d.enforceDurations(options.MinDuration, options.MaxDuration)
+	}
+	if options.MaxLineLength > 0 {
+		maxLines := options.MaxLines
+		if maxLines <= 0 {
+			maxLines = 2
+		}
+		for i := range d.Cues {
+			d.Cues[i].Text = wrapText(d.Cues[i].Text, options.MaxLineLength, maxLines)
+		}
+	}
+}
+
+// stripAnnotations removes sound descriptions and music notes
+func stripAnnotations(text string) string {
+	text = bracketAnnotationRe.ReplaceAllString(text, "")
+	text = musicNoteRe.ReplaceAllString(text, "")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		// Parentheses only count as annotation when they are the whole line
+		if parenLineRe.MatchString(strings.TrimSpace(line)) {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stripSpeakerMarkers removes speaker change markers at line starts
+func stripSpeakerMarkers(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		line = speakerArrowRe.ReplaceAllString(line, "")
+		line = speakerDashRe.ReplaceAllString(line, "")
+		line = speakerNameRe.ReplaceAllString(line, "")
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// normalizeLines collapses spaces and drops empty lines
+func normalizeLines(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// collapseRolling drops lines already shown by the previous cue
+func (d *Document) collapseRolling() {
+	var previous []string
+	for i := range d.Cues {
+		lines := strings.Split(d.Cues[i].Text, "\n")
+		var kept []string
+		for _, line := range lines {
+			if !containsString(previous, PlainText(line)) {
+				kept = append(kept, line)
+			}
+		}
+
+		previous = previous[:0]
+		for _, line := range lines {
+			previous = append(previous, PlainText(line))
+		}
+		d.Cues[i].Text = strings.Join(kept, "\n")
+	}
+}
+
+// dropEmpty removes cues without text
+func (d *Document) dropEmpty() {
+	cues := d.Cues[:0]
+	for _, cue := range d.Cues {
+		if strings.TrimSpace(PlainText(cue.Text)) != "" {
+			cues = append(cues, cue)
+		}
+	}
+	d.Cues = cues
+}
+
+// fixOverlaps ends each cue no later than the next one starts
+func (d *Document) fixOverlaps() {
+	for i := 0; i+1 < len(d.Cues); i++ {
+		if d.Cues[i].End > d.Cues[i+1].Start {
+			d.Cues[i].End = d.Cues[i+1].Start
+		}
+	}
+
+	// Cues cut down to nothing are merged into their successor
+	cues := d.Cues[:0]
+	for i, cue := range d.Cues {
+		if cue.End <= cue.Start && i+1 < len(d.Cues) {
+			next := &d.Cues[i+1]
+			next.Start = cue.Start
+			next.Text = cue.Text + "\n" + next.Text
+			continue
+		}
+		cues = append(cues, cue)
+	}
+	d.Cues = cues
+}
+
+// mergeShort joins cues shorter than the threshold with the next cue when
+// they are close in time and the result still fits the line limits
+func (d *Document) mergeShort(options CleanupOptions) {
+	const maxGap = 500 * time.Millisecond
+
+	limit := 0
+	if options.MaxLineLength > 0 {
+		maxLines := options.MaxLines
+		if maxLines <= 0 {
+			maxLines = 2
+		}
+		limit = options.MaxLineLength * maxLines
+	}
+
+	var cues []Cue
+	for i := 0; i < len(d.Cues); i++ {
+		cue := d.Cues[i]
+		for i+1 < len(d.Cues) && cue.End-cue.Start < options.MergeShorterThan {
+			next := d.Cues[i+1]
+			joined := cue.Text + " " + next.Text
+			if next.Start-cue.End > maxGap || (limit > 0 && utf8.RuneCountInString(PlainText(joined)) > limit) {
+				break
+			}
+			cue.Text = strings.ReplaceAll(joined, "\n", " ")
+			cue.End = next.End
+			i++
+		}
+		cues = append(cues, cue)
+	}
+	d.Cues = cues
+}
+
+// enforceDurations applies minimum and maximum on-screen time
+func (d *Document) enforceDurations(minDuration, maxDuration time.Duration) {
+	for i := range d.Cues {
+		cue := &d.Cues[i]
+		if maxDuration > 0 && cue.End-cue.Start > maxDuration {
+			cue.End = cue.Start + maxDuration
+		}
+		if minDuration > 0 && cue.End-cue.Start < minDuration {
+			end := cue.Start + minDuration
+			if i+1 < len(d.Cues) && end > d.Cues[i+1].Start {
+				end = d.Cues[i+1].Start
+			}
+			if end > cue.End {
+				cue.End = end
+			}
+		}
+	}
+}
+
+// wrapText re-wraps text to lines of at most width characters. When the
+// text needs more than maxLines lines, lines are balanced instead of
+// overflowing into extra ones.
+func wrapText(text string, width, maxLines int) string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return ""
+	}
+
+	total := utf8.RuneCountInString(PlainText(strings.Join(words, " ")))
+	if total > width*maxLines {
+		width = (total + maxLines - 1) / maxLines
+	}
+
+	// Word breaks can still push text onto an extra line: widen until it fits
+	lines := wrapWords(words, width)
+	for len(lines) > maxLines && width < total {
+		width++
+		lines = wrapWords(words, width)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrapWords fills lines of at most width characters, a longer word gets a
+// line of its own
+func wrapWords(words []string, width int) []string {
+	var lines []string
+	var line string
+	for _, word := range words {
+		if line == "" {
+			line = word
+			continue
+		}
+		if utf8.RuneCountInString(PlainText(line+" "+word)) > width {
+			lines = append(lines, line)
+			line = word
+			continue
+		}
+		line += " " + word
+	}
+	return append(lines, line)
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
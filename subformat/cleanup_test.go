@@ -0,0 +1,177 @@
+package subformat
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// cue builds a cue with times in milliseconds
+func cue(start, end int, text string) Cue {
+	return Cue{Start: ms(start), End: ms(end), Text: text}
+}
+
+func TestCleanupText(t *testing.T) {
+	tests := []struct {
+		name    string
+		options CleanupOptions
+		text    string
+		want    string
+	}{
+		{"tags", CleanupOptions{StripTags: true}, "<c.colorE5E5E5>hello</c><00:00:01.000><c> <i>world</i></c>", "hello world"},
+		{"tags off", CleanupOptions{StripAnnotations: true}, "<i>hello</i>", "<i>hello</i>"},
+		{"brackets and notes", CleanupOptions{StripAnnotations: true}, "[Music] ♪ la la ♪", "la la"},
+		{"parenthesised line", CleanupOptions{StripAnnotations: true}, "(applause)\nthank you", "thank you"},
+		{"parentheses inside a line stay", CleanupOptions{StripAnnotations: true}, "she said (quietly) hi", "she said (quietly) hi"},
+		{"speaker arrows and names", CleanupOptions{StripSpeakerMarkers: true}, ">> JOHN: hi there", "hi there"},
+		{"dialogue dashes", CleanupOptions{StripSpeakerMarkers: true}, "- Hi.\n- Bye.", "Hi.\nBye."},
+		{"cyrillic speaker", CleanupOptions{StripSpeakerMarkers: true}, "ИВАН: привет", "привет"},
+		{"lowercase colon is text", CleanupOptions{StripSpeakerMarkers: true}, "note: this stays", "note: this stays"},
+		{"spaces and empty lines", CleanupOptions{StripTags: true}, "  a   b \n\n c ", "a b\nc"},
+	}
+	for _, tt := range tests {
+		doc := &Document{Cues: []Cue{cue(0, 1000, tt.text)}}
+		doc.Cleanup(tt.options)
+		if len(doc.Cues) != 1 || doc.Cues[0].Text != tt.want {
+			t.Errorf("%s: Cleanup(%q) = %+v, want %q", tt.name, tt.text, doc.Cues, tt.want)
+		}
+	}
+}
+
+func TestCleanupSteps(t *testing.T) {
+	tests := []struct {
+		name    string
+		options CleanupOptions
+		cues    []Cue
+		want    []Cue
+	}{
+		{
+			name:    "annotation-only cues are dropped",
+			options: CleanupOptions{StripAnnotations: true},
+			cues:    []Cue{cue(0, 1000, "[Music]"), cue(1000, 2000, "words")},
+			want:    []Cue{cue(1000, 2000, "words")},
+		},
+		{
+			name:    "rolling captions keep only new lines",
+			options: CleanupOptions{CollapseRolling: true},
+			cues: []Cue{
+				cue(0, 2000, "one"),
+				cue(2000, 4000, "one\ntwo"),
+				cue(4000, 6000, "two\nthree"),
+				cue(6000, 8000, "two\nthree"), // nothing new
+				cue(8000, 9000, "<c>three</c>\nfour"),
+			},
+			want: []Cue{
+				cue(0, 2000, "one"),
+				cue(2000, 4000, "two"),
+				cue(4000, 6000, "three"),
+				cue(8000, 9000, "four"),
+			},
+		},
+		{
+			name:    "unsorted cues are sorted first",
+			options: CleanupOptions{FixOverlaps: true},
+			cues:    []Cue{cue(2000, 3000, "b"), cue(0, 1000, "a")},
+			want:    []Cue{cue(0, 1000, "a"), cue(2000, 3000, "b")},
+		},
+		{
+			name:    "overlaps are cut at the next start",
+			options: CleanupOptions{FixOverlaps: true},
+			cues:    []Cue{cue(0, 2500, "a"), cue(2000, 3000, "b"), cue(3000, 4000, "c")},
+			want:    []Cue{cue(0, 2000, "a"), cue(2000, 3000, "b"), cue(3000, 4000, "c")},
+		},
+		{
+			name:    "a cue cut to nothing joins the next one",
+			options: CleanupOptions{FixOverlaps: true},
+			cues:    []Cue{cue(1000, 2000, "a"), cue(1000, 3000, "b")},
+			want:    []Cue{cue(1000, 3000, "a\nb")},
+		},
+		{
+			name:    "short cues merge into close neighbours",
+			options: CleanupOptions{MergeShorterThan: 700 * time.Millisecond},
+			cues:    []Cue{cue(0, 200, "a"), cue(250, 400, "b"), cue(450, 1500, "c\nd"), cue(1500, 3000, "e")},
+			want:    []Cue{cue(0, 1500, "a b c d"), cue(1500, 3000, "e")},
+		},
+		{
+			name:    "no merge across a long gap",
+			options: CleanupOptions{MergeShorterThan: 700 * time.Millisecond},
+			cues:    []Cue{cue(0, 300, "a"), cue(1000, 2000, "b")},
+			want:    []Cue{cue(0, 300, "a"), cue(1000, 2000, "b")},
+		},
+		{
+			name:    "no merge past the line limits",
+			options: CleanupOptions{MergeShorterThan: 700 * time.Millisecond, MaxLineLength: 8, MaxLines: 1},
+			cues:    []Cue{cue(0, 300, "hello"), cue(400, 1000, "world")},
+			want:    []Cue{cue(0, 300, "hello"), cue(400, 1000, "world")},
+		},
+		{
+			name:    "minimum duration stops at the next cue",
+			options: CleanupOptions{MinDuration: time.Second},
+			cues:    []Cue{cue(0, 300, "a"), cue(500, 700, "b")},
+			want:    []Cue{cue(0, 500, "a"), cue(500, 1500, "b")},
+		},
+		{
+			name:    "minimum duration never shortens",
+			options: CleanupOptions{MinDuration: time.Second},
+			cues:    []Cue{cue(0, 1500, "a"), cue(1000, 3000, "b")},
+			want:    []Cue{cue(0, 1500, "a"), cue(1000, 3000, "b")},
+		},
+		{
+			name:    "maximum duration",
+			options: CleanupOptions{MaxDuration: 7 * time.Second},
+			cues:    []Cue{cue(0, 10000, "a"), cue(10000, 12000, "b")},
+			want:    []Cue{cue(0, 7000, "a"), cue(10000, 12000, "b")},
+		},
+		{
+			name:    "lines are wrapped",
+			options: CleanupOptions{MaxLineLength: 10, MaxLines: 2},
+			cues:    []Cue{cue(0, 1000, "one two three four")},
+			want:    []Cue{cue(0, 1000, "one two\nthree four")},
+		},
+		{
+			name:    "long text is balanced over the line limit",
+			options: CleanupOptions{MaxLineLength: 8, MaxLines: 2},
+			cues:    []Cue{cue(0, 1000, "aaaa bbbb cccc dddd eeee")},
+			want:    []Cue{cue(0, 1000, "aaaa bbbb cccc\ndddd eeee")},
+		},
+		{
+			name:    "two lines by default",
+			options: CleanupOptions{MaxLineLength: 4},
+			cues:    []Cue{cue(0, 1000, "aaaa bbbb cccc")},
+			want:    []Cue{cue(0, 1000, "aaaa bbbb\ncccc")},
+		},
+	}
+	for _, tt := range tests {
+		doc := &Document{Cues: append([]Cue(nil), tt.cues...)}
+		doc.Cleanup(tt.options)
+		if !reflect.DeepEqual(doc.Cues, tt.want) {
+			t.Errorf("%s:\n got  %+v\n want %+v", tt.name, doc.Cues, tt.want)
+		}
+	}
+}
+
+func TestCleanupDefaults(t *testing.T) {
+	if (CleanupOptions{}).Enabled() || !DefaultCleanupOptions.Enabled() {
+		t.Error("Enabled() must be false only for the zero options")
+	}
+
+	// YouTube auto captions: rolling two-line window with karaoke timings
+	doc := &Document{Cues: []Cue{
+		cue(0, 2000, "[Music]"),
+		cue(2000, 4000, "<00:00:02.000><c>so</c><00:00:02.500><c> today</c>"),
+		cue(4000, 4010, "so today"),
+		cue(4010, 6000, "so today\n>> we look at subtitles"),
+		cue(6000, 6500, "we look at subtitles\nand"),
+		cue(6500, 20000, "and\nhow to clean them"),
+	}}
+	doc.Cleanup(DefaultCleanupOptions)
+
+	want := []Cue{
+		cue(2000, 4000, "so today"),
+		cue(4010, 6000, "we look at subtitles"),
+		cue(6000, 13000, "and how to clean them"), // merged, then capped at 7s,
+	}
+	if !reflect.DeepEqual(doc.Cues, want) {
+		t.Errorf("Cleanup(DefaultCleanupOptions):\n got  %+v\n want %+v", doc.Cues, want)
+	}
+}
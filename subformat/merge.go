@@ -0,0 +1,151 @@
+package subformat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SecondaryStyleName is the ASS style used for the second language
+const SecondaryStyleName = "Secondary"
+
+// MergeOptions controls how two languages are combined
+type MergeOptions struct {
+	MinOverlap      float64 // share of the shorter cue that must overlap to pair (default 0.3)
+	SecondaryColour string  // #RRGGBB for secondary lines in SRT/VTT, "" = none
+	SecondaryItalic bool    // italicize secondary lines in SRT/VTT
+	Styled          bool    // ASS: secondary lines as separate events with the Secondary style
+}
+
+// DefaultMergeOptions keeps the second language readable but distinct
+var DefaultMergeOptions = MergeOptions{
+	MinOverlap:      0.3,
+	SecondaryItalic: true,
+}
+
+// MergeBilingual combines two subtitle documents by timestamp. Each secondary
+// cue is paired with the primary cue it overlaps most; its text goes on an
+// extra line of that cue. Cues without a counterpart are kept on their own,
+// so no text from either language is lost.
+func MergeBilingual(primary, secondary *Document, options MergeOptions) *Document {
+	if options.MinOverlap <= 0 {
+		options.MinOverlap = DefaultMergeOptions.MinOverlap
+	}
+
+	primaryCues := append([]Cue(nil), primary.Cues...)
+	secondaryCues := append([]Cue(nil), secondary.Cues...)
+	sortCues(primaryCues)
+	sortCues(secondaryCues)
+
+	merged := &Document{}
+	if options.Styled {
+		merged.Styles = bilingualStyles()
+		for _, cue := range primaryCues {
+			cue.Style = merged.Styles[0].Name
+			merged.Cues = append(merged.Cues, cue)
+		}
+		for _, cue := range secondaryCues {
+			cue.Style = SecondaryStyleName
+			merged.Cues = append(merged.Cues, cue)
+		}
+		sortCues(merged.Cues)
+		return merged
+	}
+
+	// Pair every secondary cue with its best-overlapping primary cue
+	paired := make([][]string, len(primaryCues))
+	var unmatched []Cue
+	for _, sec := range secondaryCues {
+		best, bestOverlap := -1, time.Duration(0)
+		for i, pri := range primaryCues {
+			if pri.Start >= sec.End {
+				break
+			}
+			if overlap := overlapOf(pri, sec); overlap > bestOverlap {
+				best, bestOverlap = i, overlap
+			}
+		}
+
+		shorter := minDuration(sec.End-sec.Start, durationOf(primaryCues, best))
+		if best < 0 || shorter <= 0 || float64(bestOverlap)/float64(shorter) < options.MinOverlap {
+			unmatched = append(unmatched, sec)
+			continue
+		}
+		paired[best] = append(paired[best], PlainText(sec.Text))
+	}
+
+	for i, cue := range primaryCues {
+		if len(paired[i]) > 0 {
+			secondaryText := strings.Join(strings.Fields(strings.Join(paired[i], " ")), " ")
+			cue.Text = cue.Text + "\n" + decorateSecondary(secondaryText, options)
+		}
+		merged.Cues = append(merged.Cues, cue)
+	}
+	for _, cue := range unmatched {
+		cue.Text = decorateSecondary(PlainText(cue.Text), options)
+		merged.Cues = append(merged.Cues, cue)
+	}
+
+	sortCues(merged.Cues)
+	return merged
+}
+
+// bilingualStyles returns the primary and secondary ASS styles
+func bilingualStyles() []Style {
+	primary := DefaultStyle()
+
+	secondary := DefaultStyle()
+	secondary.Name = SecondaryStyleName
+	secondary.FontSize = primary.FontSize * 0.8
+	secondary.PrimaryColour = "&H0000FFFF" // yellow
+	secondary.Italic = true
+	secondary.Alignment = 8 // top of the frame, away from the primary line
+
+	return []Style{primary, secondary}
+}
+
+// decorateSecondary styles the second language for formats without styles
+func decorateSecondary(text string, options MergeOptions) string {
+	if options.SecondaryColour != "" {
+		text = fmt.Sprintf(`<font color="%s">%s</font>`, options.SecondaryColour, text)
+	}
+	if options.SecondaryItalic {
+		text = "<i>" + text + "</i>"
+	}
+	return text
+}
+
+// overlapOf returns how long two cues are on screen together
+func overlapOf(a, b Cue) time.Duration {
+	start, end := a.Start, a.End
+	if b.Start > start {
+		start = b.Start
+	}
+	if b.End < end {
+		end = b.End
+	}
+	if end <= start {
+		return 0
+	}
+	return end - start
+}
+
+func durationOf(cues []Cue, i int) time.Duration {
+	if i < 0 {
+		return 0
+	}
+	return cues[i].End - cues[i].Start
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// sortCues orders cues by start time, keeping the order of equal starts
+func sortCues(cues []Cue) {
+	sort.SliceStable(cues, func(i, j int) bool { return cues[i].Start < cues[j].Start })
+}
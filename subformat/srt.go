@@ -0,0 +1,118 @@
+package subformat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseSRT parses SubRip text
+func parseSRT(text string) (*Document, error) {
+	lines := strings.Split(text, "\n")
+	doc := &Document{}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if !strings.Contains(line, "-->") {
+			continue // index lines and stray text
+		}
+
+		start, end, _, err := parseTimingLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+
+		// Text runs until a blank line or the next "index + timing" pair
+		var textLines []string
+		for i+1 < len(lines) {
+			next := strings.TrimRight(lines[i+1], " \t")
+			if next == "" || isSRTIndex(lines, i+1) {
+				break
+			}
+			textLines = append(textLines, next)
+			i++
+		}
+
+		cueText, pos := extractSRTOverrides(strings.Join(textLines, "\n"))
+		doc.Cues = append(doc.Cues, Cue{
+			Start:    start,
+			End:      end,
+			Text:     cueText,
+			Position: pos,
+		})
+	}
+
+	if len(doc.Cues) == 0 {
+		return nil, fmt.Errorf("no cues found")
+	}
+	return doc, nil
+}
+
+// isSRTIndex reports whether lines[i] is a cue number followed by timing
+func isSRTIndex(lines []string, i int) bool {
+	if _, err := strconv.Atoi(strings.TrimSpace(lines[i])); err != nil {
+		return false
+	}
+	return i+1 < len(lines) && strings.Contains(lines[i+1], "-->")
+}
+
+// parseTimingLine parses "start --> end [settings]"
+func parseTimingLine(line string) (start, end time.Duration, settings string, err error) {
+	left, right, found := strings.Cut(line, "-->")
+	if !found {
+		return 0, 0, "", fmt.Errorf("missing '-->' in timing line")
+	}
+
+	fields := strings.Fields(right)
+	if len(fields) == 0 {
+		return 0, 0, "", fmt.Errorf("missing end time")
+	}
+
+	if start, err = ParseTimestamp(left); err != nil {
+		return 0, 0, "", err
+	}
+	if end, err = ParseTimestamp(fields[0]); err != nil {
+		return 0, 0, "", err
+	}
+	return start, end, strings.Join(fields[1:], " "), nil
+}
+
+// encodeSRT renders the document as SubRip
+func encodeSRT(d *Document) []byte {
+	var b strings.Builder
+	for i, cue := range d.Cues {
+		text := toSRTText(styledText(d, cue))
+		if cue.Position.Align != 0 && cue.Position.Align != 2 {
+			text = fmt.Sprintf(`{\an%d}`, cue.Position.Align) + text
+		}
+
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n",
+			i+1, formatSRTTime(cue.Start), formatSRTTime(cue.End), text)
+	}
+	return []byte(b.String())
+}
+
+// styledText applies the cue's ASS style (bold/italic/colour) as inline tags
+// so the look survives conversion to formats without styles
+func styledText(d *Document, cue Cue) string {
+	style, ok := d.StyleByName(cue.Style)
+	if !ok {
+		return cue.Text
+	}
+
+	text := cue.Text
+	if colour := assColourToHTML(style.PrimaryColour); style.PrimaryColour != "" && colour != "#FFFFFF" {
+		text = fmt.Sprintf(`<font color="%s">%s</font>`, colour, text)
+	}
+	if style.Underline {
+		text = "<u>" + text + "</u>"
+	}
+	if style.Italic {
+		text = "<i>" + text + "</i>"
+	}
+	if style.Bold {
+		text = "<b>" + text + "</b>"
+	}
+	return text
+}
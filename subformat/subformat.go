@@ -0,0 +1,253 @@
+// Package subformat parses and writes SRT, WebVTT and ASS subtitle files
+// without external tools.
+package subformat
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported subtitle formats
+const (
+	FormatSRT = "srt"
+	FormatVTT = "vtt"
+	FormatASS = "ass"
+)
+
+// Position places a cue on screen. Align uses the ASS numpad layout
+// (1-3 bottom, 4-6 middle, 7-9 top), 0 means renderer default.
+// X and Y are percentages of the frame and only used when HasXY is set.
+type Position struct {
+	Align int
+	X, Y  float64
+	HasXY bool
+}
+
+// Cue is a single timed subtitle entry. Text keeps line breaks as "\n" and
+// inline styling as <b>, <i>, <u> and <font color="#RRGGBB"> tags; WebVTT
+// specific tags (<c>, <v>, karaoke timestamps) are kept as is.
+type Cue struct {
+	Start    time.Duration
+	End      time.Duration
+	Text     string
+	Style    string   // ASS style name, empty = default
+	Position Position // on-screen placement
+	Settings string   // raw WebVTT cue settings, kept for VTT -> VTT
+}
+
+// Style is an ASS style. Raw keeps fields we don't model for round trips.
+type Style struct {
+	Name          string
+	FontName      string
+	FontSize      float64
+	PrimaryColour string // ASS colour, e.g. &H00FFFFFF
+	OutlineColour string
+	BackColour    string
+	Bold          bool
+	Italic        bool
+	Underline     bool
+	Alignment     int
+	MarginL       int
+	MarginR       int
+	MarginV       int
+	Raw           map[string]string
+}
+
+// Document is a parsed subtitle file
+type Document struct {
+	Cues     []Cue
+	Styles   []Style // only filled for ASS sources
+	PlayResX int     // ASS script resolution
+	PlayResY int
+}
+
+// StyleByName returns the style with the given name, if present
+func (d *Document) StyleByName(name string) (Style, bool) {
+	for _, style := range d.Styles {
+		if strings.EqualFold(style.Name, name) {
+			return style, true
+		}
+	}
+	return Style{}, false
+}
+
+// IsSupported reports whether the format can be parsed and written
+func IsSupported(format string) bool {
+	switch strings.ToLower(format) {
+	case FormatSRT, FormatVTT, FormatASS:
+		return true
+	}
+	return false
+}
+
+// DetectFormat guesses the subtitle format by extension, then by content
+func DetectFormat(path string, data []byte) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	switch ext {
+	case FormatSRT, FormatVTT, FormatASS:
+		return ext
+	case "ssa":
+		return FormatASS
+	}
+
+	head := strings.TrimSpace(string(bytes.TrimPrefix(data, utf8BOM)))
+	switch {
+	case strings.HasPrefix(head, "WEBVTT"):
+		return FormatVTT
+	case strings.HasPrefix(head, "[Script Info]"):
+		return FormatASS
+	default:
+		return FormatSRT
+	}
+}
+
+// Parse parses subtitle data in the given format
+func Parse(data []byte, format string) (*Document, error) {
+	text := normalizeNewlines(string(bytes.TrimPrefix(data, utf8BOM)))
+
+	switch strings.ToLower(format) {
+	case FormatSRT:
+		return parseSRT(text)
+	case FormatVTT:
+		return parseVTT(text)
+	case FormatASS:
+		return parseASS(text)
+	default:
+		return nil, fmt.Errorf("unsupported subtitle format: %s", format)
+	}
+}
+
+// ParseFile reads and parses a subtitle file, detecting its format
+func ParseFile(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("subtitle read error: %v", err)
+	}
+
+	doc, err := Parse(data, DetectFormat(path, data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filepath.Base(path), err)
+	}
+	return doc, nil
+}
+
+// Encode renders the document in the given format
+func (d *Document) Encode(format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case FormatSRT:
+		return encodeSRT(d), nil
+	case FormatVTT:
+		return encodeVTT(d), nil
+	case FormatASS:
+		return encodeASS(d), nil
+	default:
+		return nil, fmt.Errorf("unsupported subtitle format: %s", format)
+	}
+}
+
+// WriteFile writes the document, choosing the format by extension
+func (d *Document) WriteFile(path string) error {
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	data, err := d.Encode(format)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("subtitle write error: %v", err)
+	}
+	return nil
+}
+
+// ConvertFile converts src into dst; formats come from the file names
+func ConvertFile(src, dst string) error {
+	doc, err := ParseFile(src)
+	if err != nil {
+		return err
+	}
+	return doc.WriteFile(dst)
+}
+
+// =================== Helpers ===================
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// normalizeNewlines converts CRLF and CR line endings to LF
+func normalizeNewlines(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\r", "\n")
+}
+
+// ParseTimestamp parses SRT (00:00:01,000), VTT (00:01.000) and
+// ASS (0:00:01.00) timestamps
+func ParseTimestamp(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp: %q", s)
+	}
+
+	secPart := strings.Replace(parts[len(parts)-1], ",", ".", 1)
+	secStr, fracStr, _ := strings.Cut(secPart, ".")
+
+	var hours, minutes int
+	var err error
+	if len(parts) == 3 {
+		if hours, err = strconv.Atoi(parts[0]); err != nil {
+			return 0, fmt.Errorf("invalid timestamp: %q", s)
+		}
+	}
+	if minutes, err = strconv.Atoi(parts[len(parts)-2]); err != nil {
+		return 0, fmt.Errorf("invalid timestamp: %q", s)
+	}
+	seconds, err := strconv.Atoi(secStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp: %q", s)
+	}
+
+	// Fraction scaled to milliseconds: ".5" = 500ms, ".05" = 50ms
+	millis := 0
+	if fracStr != "" {
+		fracStr = (fracStr + "000")[:3]
+		if millis, err = strconv.Atoi(fracStr); err != nil {
+			return 0, fmt.Errorf("invalid timestamp: %q", s)
+		}
+	}
+
+	return time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds)*time.Second +
+		time.Duration(millis)*time.Millisecond, nil
+}
+
+// splitClock breaks a duration into clock parts, clamping negatives to zero
+func splitClock(d time.Duration) (h, m, s, ms int) {
+	if d < 0 {
+		d = 0
+	}
+	total := int(d / time.Millisecond)
+	return total / 3600000, total / 60000 % 60, total / 1000 % 60, total % 1000
+}
+
+// formatSRTTime formats 00:00:01,000
+func formatSRTTime(d time.Duration) string {
+	h, m, s, ms := splitClock(d)
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", h, m, s, ms)
+}
+
+// formatVTTTime formats 00:00:01.000
+func formatVTTTime(d time.Duration) string {
+	h, m, s, ms := splitClock(d)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, ms)
+}
+
+// formatASSTime formats 0:00:01.00 (centiseconds)
+func formatASSTime(d time.Duration) string {
+	h, m, s, ms := splitClock(d + 5*time.Millisecond)
+	return fmt.Sprintf("%d:%02d:%02d.%02d", h, m, s, ms/10)
+}
@@ -0,0 +1,513 @@
+package subformat
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ms builds durations from milliseconds for compact test tables
+func ms(n int) time.Duration {
+	return time.Duration(n) * time.Millisecond
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"00:00:01,000", ms(1000)},       // SRT
+		{"01:02:03,456", ms(3723456)},    // SRT with hours
+		{"00:01.500", ms(1500)},          // VTT without hours
+		{"00:00:01.250", ms(1250)},       // VTT
+		{"0:00:01.05", ms(1050)},         // ASS centiseconds
+		{"0:00:01.5", ms(1500)},          // short fraction
+		{" 00:00:02,000 ", ms(2000)},     // surrounding spaces
+		{"00:00:03", ms(3000)},           // no fraction
+		{"10:00:00.000", 10 * time.Hour}, // long video
+		{"00:00:01.2345", ms(1234)},      // extra digits are cut
+	}
+	for _, tt := range tests {
+		got, err := ParseTimestamp(tt.in)
+		if err != nil {
+			t.Errorf("ParseTimestamp(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseTimestamp(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "5", "1:2:3:4", "aa:00:01", "00:bb:01", "00:00:cc", "00:00:01.x"} {
+		if _, err := ParseTimestamp(in); err == nil {
+			t.Errorf("ParseTimestamp(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestFormatTimes(t *testing.T) {
+	d := ms(3723456)
+	if got := formatSRTTime(d); got != "01:02:03,456" {
+		t.Errorf("formatSRTTime = %q", got)
+	}
+	if got := formatVTTTime(d); got != "01:02:03.456" {
+		t.Errorf("formatVTTTime = %q", got)
+	}
+	// ASS has centiseconds and rounds to the nearest one
+	if got := formatASSTime(d); got != "1:02:03.46" {
+		t.Errorf("formatASSTime = %q", got)
+	}
+	if got := formatASSTime(ms(1994)); got != "0:00:01.99" {
+		t.Errorf("formatASSTime(1.994s) = %q", got)
+	}
+	if got := formatASSTime(ms(1995)); got != "0:00:02.00" {
+		t.Errorf("formatASSTime(1.995s) = %q", got)
+	}
+	// Negative times are clamped
+	if got := formatSRTTime(-time.Second); got != "00:00:00,000" {
+		t.Errorf("formatSRTTime(-1s) = %q", got)
+	}
+}
+
+func TestParseOffsetAndTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"1.5s", ms(1500)},
+		{"-250ms", ms(-250)},
+		{"-00:00:01.500", ms(-1500)},
+		{"+1:02.3", ms(62300)},
+	}
+	for _, tt := range tests {
+		got, err := ParseOffset(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseOffset(%q) = %s, %v; want %s", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := ParseOffset("soon"); err == nil {
+		t.Error("ParseOffset(soon) succeeded")
+	}
+	if _, err := ParseTime("-1s"); err == nil {
+		t.Error("ParseTime(-1s) succeeded, want an error for a negative time")
+	}
+}
+
+const sampleSRT = `1
+00:00:01,000 --> 00:00:02,500
+Hello <b>world</b>
+
+2
+00:00:03,000 --> 00:00:04,000
+{\an8}Two lines
+on top
+
+`
+
+func TestSRTRoundTrip(t *testing.T) {
+	doc, err := Parse([]byte(sampleSRT), FormatSRT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Cues) != 2 {
+		t.Fatalf("got %d cues, want 2", len(doc.Cues))
+	}
+	if cue := doc.Cues[1]; cue.Text != "Two lines\non top" || cue.Position.Align != 8 {
+		t.Errorf("second cue = %q align %d, want the \\an8 override moved to Position", cue.Text, cue.Position.Align)
+	}
+
+	encoded, err := doc.Encode(FormatSRT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != sampleSRT {
+		t.Errorf("SRT round trip changed the file:\n%s", encoded)
+	}
+}
+
+func TestParseSRTWindowsNewlinesAndBOM(t *testing.T) {
+	data := append([]byte{0xEF, 0xBB, 0xBF}, strings.ReplaceAll(sampleSRT, "\n", "\r\n")...)
+	doc, err := Parse(data, FormatSRT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Cues) != 2 || doc.Cues[0].Text != "Hello <b>world</b>" {
+		t.Errorf("cues = %+v", doc.Cues)
+	}
+}
+
+func TestVTTRoundTrip(t *testing.T) {
+	input := `WEBVTT
+Kind: captions
+Language: en
+
+NOTE written by hand
+
+intro
+00:00:01.000 --> 00:00:02.000 align:start position:0%
+Fish &amp; chips
+
+00:00:02.000 --> 00:00:03.000 line:0 align:end
+<c.colorE5E5E5>top right</c>
+
+`
+	doc, err := Parse([]byte(input), FormatVTT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Cues) != 2 {
+		t.Fatalf("got %d cues, want 2", len(doc.Cues))
+	}
+	if doc.Cues[0].Text != "Fish & chips" {
+		t.Errorf("entities not unescaped: %q", doc.Cues[0].Text)
+	}
+	if doc.Cues[0].Position.Align != 0 {
+		t.Errorf("YouTube's align:start without a line must not move the cue, got %d", doc.Cues[0].Position.Align)
+	}
+	if doc.Cues[1].Position.Align != 9 {
+		t.Errorf("line:0 align:end = %d, want 9", doc.Cues[1].Position.Align)
+	}
+
+	encoded, err := doc.Encode(FormatVTT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `WEBVTT
+
+00:00:01.000 --> 00:00:02.000 align:start position:0%
+Fish &amp; chips
+
+00:00:02.000 --> 00:00:03.000 line:0 align:end
+<c.colorE5E5E5>top right</c>
+
+`
+	if string(encoded) != want {
+		t.Errorf("VTT round trip:\n%s\nwant:\n%s", encoded, want)
+	}
+}
+
+func TestPositionFromVTT(t *testing.T) {
+	tests := []struct {
+		settings string
+		want     Position
+	}{
+		{"", Position{}},
+		{"align:start position:0%", Position{}},
+		{"line:0", Position{Align: 8}},
+		{"line:-1", Position{Align: 2}},
+		{"line:0 align:start", Position{Align: 7}},
+		{"line:50% align:end", Position{Align: 6}},
+		{"line:90%", Position{Align: 2}},
+		{"line:10%,start position:25%", Position{Align: 8, X: 25, Y: 10, HasXY: true}},
+		{"line:abc", Position{}},
+	}
+	for _, tt := range tests {
+		if got := positionFromVTT(tt.settings); got != tt.want {
+			t.Errorf("positionFromVTT(%q) = %+v, want %+v", tt.settings, got, tt.want)
+		}
+	}
+}
+
+func TestVTTSettingsRoundTrip(t *testing.T) {
+	// The bottom row has no line setting, and horizontal alignment alone
+	// is ignored, so it comes back as the renderer default
+	want := []int{1: 0, 2: 0, 3: 0, 4: 4, 5: 5, 6: 6, 7: 7, 8: 8, 9: 9}
+	for align := 1; align <= 9; align++ {
+		settings := vttSettings(Position{Align: align})
+		if got := positionFromVTT(settings).Align; got != want[align] {
+			t.Errorf("align %d -> %q -> %d, want %d", align, settings, got, want[align])
+		}
+	}
+
+	pos := Position{X: 30, Y: 20, HasXY: true}
+	if got := positionFromVTT(vttSettings(pos)); got.X != 30 || got.Y != 20 || !got.HasXY {
+		t.Errorf("XY position round trip = %+v", got)
+	}
+}
+
+const sampleASS = `[Script Info]
+ScriptType: v4.00+
+PlayResX: 1280
+PlayResY: 720
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Default,Arial,48,&H00FFFFFF,&H000000FF,&H00000000,&H80000000,0,0,0,0,100,100,0,0,1,2,1,2,20,20,30,1
+Style: Sign,Verdana,36.5,&H0000FFFF,&H000000FF,&H00000000,&H80000000,-1,-1,0,0,100,100,0,0,1,3,0,8,10,10,15,1
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: 0,0:00:01.00,0:00:02.50,Default,,0,0,0,,Hello, world
+Dialogue: 0,0:00:03.00,0:00:04.00,Sign,,0,0,0,,{\an8}Top sign
+`
+
+func TestASSRoundTrip(t *testing.T) {
+	doc, err := Parse([]byte(sampleASS), FormatASS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.PlayResX != 1280 || doc.PlayResY != 720 {
+		t.Errorf("PlayRes = %dx%d", doc.PlayResX, doc.PlayResY)
+	}
+	if len(doc.Cues) != 2 || doc.Cues[0].Text != "Hello, world" {
+		t.Fatalf("cues = %+v; commas in the text field must survive", doc.Cues)
+	}
+	if doc.Cues[1].Position.Align != 8 || doc.Cues[1].Style != "Sign" {
+		t.Errorf("second cue = %+v", doc.Cues[1])
+	}
+
+	encoded, err := doc.Encode(FormatASS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := Parse(encoded, FormatASS)
+	if err != nil {
+		t.Fatalf("re-parse: %v\n%s", err, encoded)
+	}
+	if !reflect.DeepEqual(again.Cues, doc.Cues) {
+		t.Errorf("cues changed in the round trip:\n%+v\n%+v", doc.Cues, again.Cues)
+	}
+	if !reflect.DeepEqual(again.Styles, doc.Styles) {
+		t.Errorf("styles changed in the round trip:\n%+v\n%+v", doc.Styles, again.Styles)
+	}
+
+	sign, ok := again.StyleByName("sign")
+	if !ok {
+		t.Fatal("StyleByName is case-insensitive")
+	}
+	want := Style{Name: "Sign", FontName: "Verdana", FontSize: 36.5, Bold: true, Italic: true, Alignment: 8, MarginL: 10, MarginR: 10, MarginV: 15}
+	if sign.Name != want.Name || sign.FontName != want.FontName || sign.FontSize != want.FontSize ||
+		sign.Bold != want.Bold || sign.Italic != want.Italic || sign.Underline ||
+		sign.Alignment != want.Alignment || sign.MarginL != want.MarginL || sign.MarginV != want.MarginV {
+		t.Errorf("Sign style = %+v", sign)
+	}
+	if sign.Raw["Outline"] != "3" || sign.Raw["Shadow"] != "0" {
+		t.Errorf("unmodeled fields lost: %v", sign.Raw)
+	}
+}
+
+func TestASSDefaultsAndConversion(t *testing.T) {
+	doc, err := Parse([]byte(sampleSRT), FormatSRT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := doc.Encode(FormatASS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(encoded)
+	if !strings.Contains(text, "PlayResX: 1920\nPlayResY: 1080") || !strings.Contains(text, "Style: Default,Arial,64,") {
+		t.Errorf("generated ASS lacks the default resolution or style:\n%s", text)
+	}
+
+	back, err := Parse(encoded, FormatASS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srt, err := back.Encode(FormatSRT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(srt) != sampleSRT {
+		t.Errorf("SRT -> ASS -> SRT changed the file:\n%s", srt)
+	}
+}
+
+func TestStyledTextInConversion(t *testing.T) {
+	doc, err := Parse([]byte(sampleASS), FormatASS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srt, err := doc.Encode(FormatSRT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The Sign style is bold, italic and yellow (&H0000FFFF is BGR)
+	if !strings.Contains(string(srt), `<b><i><font color="#FFFF00">Top sign</font></i></b>`) {
+		t.Errorf("style not applied as inline tags:\n%s", srt)
+	}
+}
+
+func TestMalformedInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format string
+		errMsg string
+	}{
+		{"VTT without header", "00:00:01.000 --> 00:00:02.000\nHi\n", FormatVTT, "missing WEBVTT header"},
+		{"VTT bad timing", "WEBVTT\n\n00:00:xx.000 --> 00:00:02.000\nHi\n", FormatVTT, "cue 1"},
+		{"VTT missing end", "WEBVTT\n\n00:00:01.000 -->\nHi\n", FormatVTT, "missing end time"},
+		{"ASS Dialogue before Format", "[Events]\nDialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,Hi\n", FormatASS, "Dialogue before Format"},
+		{"ASS short dialogue", "[Events]\nFormat: Layer, Start, End, Style, Text\nDialogue: 0,0:00:01.00\n", FormatASS, "dialogue has 2 fields, want 5"},
+		{"ASS bad time", "[Events]\nFormat: Start, End, Text\nDialogue: 1.00,0:00:02.00,Hi\n", FormatASS, "invalid timestamp"},
+		{"SRT without cues", "just some text\n", FormatSRT, "no cues found"},
+		{"SRT bad timing", "1\n00:00:01,000 --> later\nHi\n", FormatSRT, "line 2"},
+		{"unknown format", "", "sub", "unsupported subtitle format"},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.data), tt.format)
+		if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+			t.Errorf("%s: error = %v, want it to contain %q", tt.name, err, tt.errMsg)
+		}
+	}
+}
+
+func TestVTTCueIDWithoutTiming(t *testing.T) {
+	input := "WEBVTT\n\norphan-id\n\n2\n00:00:01.000 --> 00:00:02.000\nkept\n\nonly an id\nand text\n"
+	doc, err := Parse([]byte(input), FormatVTT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Cues) != 1 || doc.Cues[0].Text != "kept" {
+		t.Errorf("cues = %+v, want only the timed one", doc.Cues)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		path, data, want string
+	}{
+		{"a.srt", "", FormatSRT},
+		{"a.VTT", "", FormatVTT},
+		{"a.ssa", "", FormatASS},
+		{"a.txt", "\ufeffWEBVTT\n", FormatVTT},
+		{"a.txt", "[Script Info]\n", FormatASS},
+		{"a.txt", "1\n00:00:01,000 --> 00:00:02,000\n", FormatSRT},
+	}
+	for _, tt := range tests {
+		if got := DetectFormat(tt.path, []byte(tt.data)); got != tt.want {
+			t.Errorf("DetectFormat(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRetiming(t *testing.T) {
+	newDoc := func() *Document {
+		return &Document{Cues: []Cue{
+			{Start: ms(1000), End: ms(2000), Text: "one"},
+			{Start: ms(5000), End: ms(6000), Text: "<00:00:05.500>two"},
+		}}
+	}
+
+	doc := newDoc()
+	doc.Shift(ms(-1500))
+	if doc.Cues[0].Start != 0 || doc.Cues[0].End != ms(500) {
+		t.Errorf("Shift must clamp at zero: %+v", doc.Cues[0])
+	}
+	if doc.Cues[1].Text != "<00:00:04.000>two" {
+		t.Errorf("inline timestamp not shifted: %q", doc.Cues[1].Text)
+	}
+
+	doc = newDoc()
+	doc.ShiftRange(TimeRange{Start: ms(4000), End: ms(10000)}, time.Second)
+	if doc.Cues[0].Start != ms(1000) || doc.Cues[1].Start != ms(6000) {
+		t.Errorf("ShiftRange moved the wrong cues: %+v", doc.Cues)
+	}
+
+	// A cue moved into a later range keeps the offset of its own range
+	doc = &Document{Cues: []Cue{
+		{Start: ms(8000), End: ms(9000), Text: "first range"},
+		{Start: ms(12000), End: ms(13000), Text: "second range"},
+		{Start: ms(25000), End: ms(26000), Text: "outside"},
+	}}
+	doc.ShiftRanges([]RangeShift{
+		{Range: TimeRange{Start: 0, End: ms(10000)}, Offset: ms(5000)},
+		{Range: TimeRange{Start: ms(10000), End: ms(20000)}, Offset: ms(1000)},
+	})
+	for i, want := range []time.Duration{ms(13000), ms(13000), ms(25000)} {
+		if doc.Cues[i].Start != want {
+			t.Errorf("ShiftRanges: %s starts at %s, want %s", doc.Cues[i].Text, doc.Cues[i].Start, want)
+		}
+	}
+
+	doc = newDoc()
+	if err := doc.Sync(SyncPoint{From: ms(1000), To: ms(2000)}, SyncPoint{From: ms(5000), To: ms(10000)}); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Cues[0].Start != ms(2000) || doc.Cues[1].Start != ms(10000) || doc.Cues[0].End != ms(4000) {
+		t.Errorf("Sync = %+v", doc.Cues)
+	}
+	if err := newDoc().Sync(SyncPoint{From: 1, To: 1}, SyncPoint{From: 1, To: 2}); err == nil {
+		t.Error("Sync with equal source times succeeded")
+	}
+	if err := newDoc().Sync(SyncPoint{From: 1, To: 5}, SyncPoint{From: 2, To: 1}); err == nil {
+		t.Error("Sync reversing the timeline succeeded")
+	}
+
+	doc = newDoc()
+	doc.Keep([]TimeRange{{Start: 0, End: ms(1500)}, {Start: ms(4000), End: ms(8000)}})
+	want := []Cue{
+		{Start: ms(1000), End: ms(1500), Text: "one"},
+		{Start: ms(2500), End: ms(3500), Text: "<00:00:03.000>two"},
+	}
+	if !reflect.DeepEqual(doc.Cues, want) {
+		t.Errorf("Keep = %+v, want %+v", doc.Cues, want)
+	}
+}
+
+func TestMergeBilingual(t *testing.T) {
+	primary := &Document{Cues: []Cue{
+		{Start: ms(10000), End: ms(12000), Text: "Only primary"},
+		{Start: 0, End: ms(2000), Text: "Hello"},
+		{Start: ms(2000), End: ms(4000), Text: "World"},
+	}}
+	secondary := &Document{Cues: []Cue{
+		{Start: ms(100), End: ms(1900), Text: "Привет"},
+		{Start: ms(1800), End: ms(2300), Text: "and"}, // overlaps both, more of World
+		{Start: ms(2100), End: ms(3000), Text: "<b>мир</b>"},
+		{Start: ms(3000), End: ms(3900), Text: "!"},
+		{Start: ms(6000), End: ms(7000), Text: "Only secondary"},
+		{Start: ms(11900), End: ms(14000), Text: "barely overlapping"},
+	}}
+
+	merged := MergeBilingual(primary, secondary, DefaultMergeOptions)
+	want := []string{
+		"0s Hello\n<i>Привет</i>",
+		"2s World\n<i>and мир !</i>",
+		"6s <i>Only secondary</i>",
+		"10s Only primary",
+		"11.9s <i>barely overlapping</i>",
+	}
+	var got []string
+	for _, cue := range merged.Cues {
+		got = append(got, cue.Start.String()+" "+cue.Text)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeBilingual =\n%q\nwant\n%q", got, want)
+	}
+	if primary.Cues[0].Text != "Only primary" || len(secondary.Cues) != 6 {
+		t.Error("MergeBilingual changed its inputs")
+	}
+
+	// A stricter overlap leaves the straddling cue on its own
+	strict := MergeBilingual(primary, secondary, MergeOptions{MinOverlap: 0.9})
+	if len(strict.Cues) != 6 || strict.Cues[1].Text != "and" {
+		t.Errorf("MinOverlap 0.9: %+v", strict.Cues)
+	}
+
+	colour := MergeBilingual(primary, secondary, MergeOptions{SecondaryColour: "#FFFF00"})
+	if colour.Cues[0].Text != "Hello\n<font color=\"#FFFF00\">Привет</font>" {
+		t.Errorf("coloured secondary line = %q", colour.Cues[0].Text)
+	}
+}
+
+func TestMergeBilingualStyled(t *testing.T) {
+	primary := &Document{Cues: []Cue{{Start: 0, End: ms(2000), Text: "Hello"}}}
+	secondary := &Document{Cues: []Cue{{Start: ms(100), End: ms(1900), Text: "Привет"}}}
+
+	merged := MergeBilingual(primary, secondary, MergeOptions{Styled: true})
+	if len(merged.Styles) != 2 || merged.Styles[1].Name != SecondaryStyleName {
+		t.Fatalf("styles = %+v", merged.Styles)
+	}
+	if merged.Styles[1].Alignment != 8 || !merged.Styles[1].Italic {
+		t.Errorf("secondary style = %+v, want italic at the top", merged.Styles[1])
+	}
+	if len(merged.Cues) != 2 || merged.Cues[0].Style != merged.Styles[0].Name || merged.Cues[1].Style != SecondaryStyleName {
+		t.Errorf("styled cues = %+v, want one event per language", merged.Cues)
+	}
+	if merged.Cues[1].Text != "Привет" {
+		t.Errorf("styled secondary text = %q, want it undecorated", merged.Cues[1].Text)
+	}
+}
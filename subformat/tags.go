@@ -0,0 +1,250 @@
+package subformat
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	anyTagRe      = regexp.MustCompile(`<[^<>]*>`)
+	basicTagRe    = regexp.MustCompile(`(?i)^</?(b|i|u)>$`)
+	fontOpenRe    = regexp.MustCompile(`(?i)^<font\s+color\s*=\s*"?(#?[0-9a-z]+)"?\s*>$`)
+	fontCloseRe   = regexp.MustCompile(`(?i)^</font>$`)
+	overrideRe    = regexp.MustCompile(`\{([^{}]*)\}`)
+	assBoldRe     = regexp.MustCompile(`^b(\d+)$`)
+	assToggleRe   = regexp.MustCompile(`^([iu])([01])$`)
+	assColourRe   = regexp.MustCompile(`^1?c(&H[0-9A-Fa-f]+&?)?$`)
+	assAlignRe    = regexp.MustCompile(`^an([1-9])$`)
+	assLegacyRe   = regexp.MustCompile(`^a(\d+)$`)
+	assPositionRe = regexp.MustCompile(`^pos\(\s*([-\d.]+)\s*,\s*([-\d.]+)\s*\)$`)
+)
+
+// PlainText removes all inline tags and styling from cue text
+func PlainText(text string) string {
+	text = overrideRe.ReplaceAllString(text, "")
+	return anyTagRe.ReplaceAllString(text, "")
+}
+
+// keepTags drops every tag for which keep returns false
+func keepTags(text string, keep func(tag string) bool) string {
+	return anyTagRe.ReplaceAllStringFunc(text, func(tag string) string {
+		if keep(tag) {
+			return tag
+		}
+		return ""
+	})
+}
+
+// isBasicTag reports tags that SRT players understand
+func isBasicTag(tag string) bool {
+	return basicTagRe.MatchString(tag) || fontOpenRe.MatchString(tag) || fontCloseRe.MatchString(tag)
+}
+
+// toSRTText keeps only b/i/u/font tags
+func toSRTText(text string) string {
+	return keepTags(text, isBasicTag)
+}
+
+// toVTTText drops font tags (not part of WebVTT) and escapes ampersands
+func toVTTText(text string) string {
+	text = keepTags(text, func(tag string) bool {
+		return !fontOpenRe.MatchString(tag) && !fontCloseRe.MatchString(tag)
+	})
+	text = strings.ReplaceAll(text, "&", "&amp;")
+	for _, entity := range []string{"lt", "gt", "nbsp", "lrm", "rlm", "amp"} {
+		text = strings.ReplaceAll(text, "&amp;"+entity+";", "&"+entity+";")
+	}
+	return text
+}
+
+// unescapeVTT decodes the entities WebVTT allows in cue text
+func unescapeVTT(text string) string {
+	return strings.NewReplacer(
+		"&lt;", "<",
+		"&gt;", ">",
+		"&nbsp;", " ",
+		"&lrm;", "\u200e",
+		"&rlm;", "\u200f",
+		"&amp;", "&",
+	).Replace(text)
+}
+
+// extractSRTOverrides reads {\anN} prefixes some SRT files carry and
+// removes any other ASS override blocks
+func extractSRTOverrides(text string) (string, Position) {
+	var pos Position
+	text = overrideRe.ReplaceAllStringFunc(text, func(block string) string {
+		for _, tok := range strings.Split(strings.Trim(block, "{}"), `\`) {
+			if m := assAlignRe.FindStringSubmatch(tok); m != nil {
+				pos.Align, _ = strconv.Atoi(m[1])
+			}
+		}
+		return ""
+	})
+	return text, pos
+}
+
+// fromASSText converts ASS dialogue text into cue text and position
+func fromASSText(text string, playResX, playResY int) (string, Position) {
+	var pos Position
+	var b strings.Builder
+	var bold, italic, underline, coloured bool
+
+	writePlain := func(s string) {
+		s = strings.ReplaceAll(s, `\N`, "\n")
+		s = strings.ReplaceAll(s, `\n`, "\n")
+		s = strings.ReplaceAll(s, `\h`, " ")
+		b.WriteString(s)
+	}
+
+	last := 0
+	for _, m := range overrideRe.FindAllStringSubmatchIndex(text, -1) {
+		writePlain(text[last:m[0]])
+		last = m[1]
+
+		for _, tok := range strings.Split(text[m[2]:m[3]], `\`) {
+			tok = strings.TrimSpace(tok)
+			switch {
+			case tok == "":
+			case assBoldRe.MatchString(tok):
+				on := assBoldRe.FindStringSubmatch(tok)[1] != "0"
+				if on != bold {
+					bold = on
+					if on {
+						b.WriteString("<b>")
+					} else {
+						b.WriteString("</b>")
+					}
+				}
+			case assToggleRe.MatchString(tok):
+				sub := assToggleRe.FindStringSubmatch(tok)
+				on := sub[2] == "1"
+				state := &italic
+				if sub[1] == "u" {
+					state = &underline
+				}
+				if on != *state {
+					*state = on
+					if on {
+						b.WriteString("<" + sub[1] + ">")
+					} else {
+						b.WriteString("</" + sub[1] + ">")
+					}
+				}
+			case assColourRe.MatchString(tok):
+				colour := assColourRe.FindStringSubmatch(tok)[1]
+				if coloured {
+					b.WriteString("</font>")
+					coloured = false
+				}
+				if colour != "" {
+					fmt.Fprintf(&b, `<font color="%s">`, assColourToHTML(colour))
+					coloured = true
+				}
+			case assAlignRe.MatchString(tok):
+				pos.Align, _ = strconv.Atoi(assAlignRe.FindStringSubmatch(tok)[1])
+			case assLegacyRe.MatchString(tok):
+				legacy, _ := strconv.Atoi(assLegacyRe.FindStringSubmatch(tok)[1])
+				pos.Align = legacyAlignment(legacy)
+			case assPositionRe.MatchString(tok):
+				sub := assPositionRe.FindStringSubmatch(tok)
+				x, _ := strconv.ParseFloat(sub[1], 64)
+				y, _ := strconv.ParseFloat(sub[2], 64)
+				if playResX > 0 && playResY > 0 {
+					pos.X = x * 100 / float64(playResX)
+					pos.Y = y * 100 / float64(playResY)
+					pos.HasXY = true
+				}
+			}
+		}
+	}
+	writePlain(text[last:])
+
+	if coloured {
+		b.WriteString("</font>")
+	}
+	if underline {
+		b.WriteString("</u>")
+	}
+	if italic {
+		b.WriteString("</i>")
+	}
+	if bold {
+		b.WriteString("</b>")
+	}
+
+	return b.String(), pos
+}
+
+// toASSText converts cue text and position into ASS dialogue text
+func toASSText(text string, pos Position, playResX, playResY int) string {
+	var prefix string
+	if pos.Align != 0 {
+		prefix += fmt.Sprintf(`\an%d`, pos.Align)
+	}
+	if pos.HasXY {
+		prefix += fmt.Sprintf(`\pos(%d,%d)`,
+			int(pos.X*float64(playResX)/100+0.5), int(pos.Y*float64(playResY)/100+0.5))
+	}
+
+	text = anyTagRe.ReplaceAllStringFunc(text, func(tag string) string {
+		if m := fontOpenRe.FindStringSubmatch(tag); m != nil {
+			return `{\c` + htmlColourToASS(m[1]) + `}`
+		}
+		if fontCloseRe.MatchString(tag) {
+			return `{\c}`
+		}
+		if basicTagRe.MatchString(tag) {
+			name := strings.ToLower(strings.Trim(tag, "</>"))
+			if strings.HasPrefix(tag, "</") {
+				return `{\` + name + `0}`
+			}
+			return `{\` + name + `1}`
+		}
+		return ""
+	})
+	text = strings.ReplaceAll(text, "\n", `\N`)
+
+	if prefix != "" {
+		text = "{" + prefix + "}" + text
+	}
+	return text
+}
+
+// legacyAlignment maps SSA \a values to numpad \an values
+func legacyAlignment(a int) int {
+	switch a {
+	case 1, 2, 3:
+		return a
+	case 5, 6, 7:
+		return a + 2
+	case 9, 10, 11:
+		return a - 5
+	}
+	return 0
+}
+
+// assColourToHTML converts &HBBGGRR& or &HAABBGGRR to #RRGGBB
+func assColourToHTML(colour string) string {
+	hex := strings.Trim(strings.TrimPrefix(strings.ToUpper(colour), "&H"), "&")
+	if len(hex) > 6 {
+		hex = hex[len(hex)-6:]
+	}
+	hex = strings.Repeat("0", 6-len(hex)) + hex
+	return "#" + hex[4:6] + hex[2:4] + hex[0:2]
+}
+
+// htmlColourToASS converts #RRGGBB to &HBBGGRR&; names other than
+// hex codes fall back to white
+func htmlColourToASS(colour string) string {
+	hex := strings.ToUpper(strings.TrimPrefix(colour, "#"))
+	if len(hex) != 6 {
+		return "&HFFFFFF&"
+	}
+	if _, err := strconv.ParseUint(hex, 16, 32); err != nil {
+		return "&HFFFFFF&"
+	}
+	return "&H" + hex[4:6] + hex[2:4] + hex[0:2] + "&"
+}
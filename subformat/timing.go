@@ -0,0 +1,164 @@
+package subformat
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// inlineTimestampRe matches WebVTT karaoke timestamps like <00:00:01.439>
+var inlineTimestampRe = regexp.MustCompile(`<((?:\d+:)?\d{2}:\d{2}\.\d{3})>`)
+
+// SyncPoint maps a time in the subtitle file to the time it should appear
+type SyncPoint struct {
+	From time.Duration
+	To   time.Duration
+}
+
+// TimeRange is a half-open interval [Start, End) of cue start times
+type TimeRange struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Contains reports whether t falls inside the range
+func (r TimeRange) Contains(t time.Duration) bool {
+	return t >= r.Start && t < r.End
+}
+
+// Retime applies fn to every cue boundary and inline WebVTT timestamp.
+// Negative results are clamped to zero.
+func (d *Document) Retime(fn func(time.Duration) time.Duration) {
+	clamp := func(t time.Duration) time.Duration {
+		if t = fn(t); t < 0 {
+			return 0
+		}
+		return t
+	}
+
+	for i := range d.Cues {
+		cue := &d.Cues[i]
+		cue.Start = clamp(cue.Start)
+		cue.End = clamp(cue.End)
+		cue.Text = inlineTimestampRe.ReplaceAllStringFunc(cue.Text, func(tag string) string {
+			t, err := ParseTimestamp(strings.Trim(tag, "<>"))
+			if err != nil {
+				return tag
+			}
+			return "<" + formatVTTTime(clamp(t)) + ">"
+		})
+	}
+}
+
+// Shift moves every cue by a constant offset
+func (d *Document) Shift(offset time.Duration) {
+	d.Retime(func(t time.Duration) time.Duration { return t + offset })
+}
+
+// RangeShift is an offset for the cues starting inside Range
+type RangeShift struct {
+	Range  TimeRange
+	Offset time.Duration
+}
+
+// ShiftRange moves only cues starting inside r by offset
+func (d *Document) ShiftRange(r TimeRange, offset time.Duration) {
+	d.ShiftRanges([]RangeShift{{Range: r, Offset: offset}})
+}
+
+// ShiftRanges moves every cue by the offset of the first range its start
+// falls in. Ranges refer to the original timing: a cue moved into another
+// range is not moved again.
+func (d *Document) ShiftRanges(shifts []RangeShift) {
+	for i := range d.Cues {
+		for _, shift := range shifts {
+			if shift.Range.Contains(d.Cues[i].Start) {
+				single := Document{Cues: d.Cues[i : i+1]}
+				single.Shift(shift.Offset)
+				break
+			}
+		}
+	}
+}
+
+// Keep maps the timeline onto the given parts joined end to end, as when
+// everything else is cut out of the media. Cues outside the parts are
+// dropped, cues spanning a cut are trimmed. parts must be sorted and must
+// not overlap.
+func (d *Document) Keep(parts []TimeRange) {
+	d.Retime(func(t time.Duration) time.Duration {
+		var kept time.Duration
+		for _, part := range parts {
+			if t < part.Start {
+				break
+			}
+			if t < part.End {
+				return kept + t - part.Start
+			}
+			kept += part.End - part.Start
+		}
+		return kept
+	})
+
+	cues := d.Cues[:0]
+	for _, cue := range d.Cues {
+		if cue.End > cue.Start {
+			cues = append(cues, cue)
+		}
+	}
+	d.Cues = cues
+}
+
+// Sync stretches timing linearly so that a.From lands on a.To and b.From
+// on b.To. Use it when the subtitles drift, e.g. after a frame rate change.
+func (d *Document) Sync(a, b SyncPoint) error {
+	if a.From == b.From {
+		return fmt.Errorf("sync points must have different source times")
+	}
+
+	scale := float64(b.To-a.To) / float64(b.From-a.From)
+	if scale <= 0 {
+		return fmt.Errorf("sync points reverse the timeline")
+	}
+
+	d.Retime(func(t time.Duration) time.Duration {
+		return a.To + time.Duration(float64(t-a.From)*scale)
+	})
+	return nil
+}
+
+// ParseOffset parses a signed offset: Go durations ("1.5s", "-250ms")
+// or timestamps ("-00:00:01.500", "+1:02.3")
+func ParseOffset(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	d, err := ParseTimestamp(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid offset: %q", s)
+	}
+	return sign * d, nil
+}
+
+// ParseTime parses an absolute position as a timestamp or Go duration
+func ParseTime(s string) (time.Duration, error) {
+	d, err := ParseOffset(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("time cannot be negative: %q", s)
+	}
+	return d, nil
+}
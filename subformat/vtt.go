@@ -0,0 +1,156 @@
+package subformat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseVTT parses WebVTT text
+func parseVTT(text string) (*Document, error) {
+	if !strings.HasPrefix(strings.TrimSpace(text), "WEBVTT") {
+		return nil, fmt.Errorf("missing WEBVTT header")
+	}
+
+	doc := &Document{}
+	blocks := strings.Split(strings.TrimSpace(text), "\n\n")
+
+	// First block is the header ("WEBVTT", "Kind: captions", ...)
+	for n, block := range blocks[1:] {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+		if len(lines) == 0 || lines[0] == "" {
+			continue
+		}
+
+		first := strings.TrimSpace(lines[0])
+		if strings.HasPrefix(first, "NOTE") || first == "STYLE" || first == "REGION" {
+			continue
+		}
+
+		// Optional cue identifier before the timing line
+		if !strings.Contains(first, "-->") {
+			lines = lines[1:]
+			if len(lines) == 0 || !strings.Contains(lines[0], "-->") {
+				continue
+			}
+		}
+
+		start, end, settings, err := parseTimingLine(strings.TrimSpace(lines[0]))
+		if err != nil {
+			return nil, fmt.Errorf("cue %d: %v", n+1, err)
+		}
+
+		doc.Cues = append(doc.Cues, Cue{
+			Start:    start,
+			End:      end,
+			Text:     unescapeVTT(strings.Join(lines[1:], "\n")),
+			Position: positionFromVTT(settings),
+			Settings: settings,
+		})
+	}
+
+	return doc, nil
+}
+
+// positionFromVTT derives a Position from WebVTT cue settings. Horizontal
+// alignment alone (YouTube sets "align:start position:0%" on every cue)
+// is ignored unless a line is given.
+func positionFromVTT(settings string) Position {
+	var pos Position
+	values := map[string]string{}
+	for _, field := range strings.Fields(settings) {
+		if key, value, ok := strings.Cut(field, ":"); ok {
+			values[key] = value
+		}
+	}
+
+	line, hasLine := values["line"]
+	if !hasLine {
+		return pos
+	}
+	line = strings.SplitN(line, ",", 2)[0]
+
+	// Vertical: 0 = bottom row, 3 = middle row, 6 = top row (numpad)
+	row := 0
+	if strings.HasSuffix(line, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(line, "%"), 64)
+		if err != nil {
+			return pos
+		}
+		switch {
+		case percent < 33:
+			row = 6
+		case percent < 66:
+			row = 3
+		}
+		if p, ok := values["position"]; ok && strings.HasSuffix(p, "%") {
+			if x, err := strconv.ParseFloat(strings.TrimSuffix(strings.SplitN(p, ",", 2)[0], "%"), 64); err == nil {
+				pos.X, pos.Y, pos.HasXY = x, percent, true
+			}
+		}
+	} else {
+		n, err := strconv.Atoi(line)
+		if err != nil {
+			return pos
+		}
+		if n >= 0 {
+			row = 6
+		}
+	}
+
+	col := 2
+	switch values["align"] {
+	case "start", "left":
+		col = 1
+	case "end", "right":
+		col = 3
+	}
+
+	pos.Align = row + col
+	return pos
+}
+
+// vttSettings renders a Position as WebVTT cue settings
+func vttSettings(pos Position) string {
+	if pos.HasXY {
+		return fmt.Sprintf("position:%.0f%% line:%.0f%%", pos.X, pos.Y)
+	}
+
+	var settings []string
+	switch {
+	case pos.Align >= 7:
+		settings = append(settings, "line:0")
+	case pos.Align >= 4:
+		settings = append(settings, "line:50%")
+	}
+	switch pos.Align {
+	case 1, 4, 7:
+		settings = append(settings, "align:start")
+	case 3, 6, 9:
+		settings = append(settings, "align:end")
+	}
+	return strings.Join(settings, " ")
+}
+
+// encodeVTT renders the document as WebVTT
+func encodeVTT(d *Document) []byte {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+
+	for _, cue := range d.Cues {
+		settings := cue.Settings
+		if settings == "" {
+			settings = vttSettings(cue.Position)
+		}
+
+		timing := formatVTTTime(cue.Start) + " --> " + formatVTTTime(cue.End)
+		if settings != "" {
+			timing += " " + settings
+		}
+
+		// Blank lines would end the cue early
+		text := strings.ReplaceAll(toVTTText(styledText(d, cue)), "\n\n", "\n")
+		fmt.Fprintf(&b, "%s\n%s\n\n", timing, text)
+	}
+	return []byte(b.String())
+}
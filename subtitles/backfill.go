@@ -0,0 +1,302 @@
+package subtitles
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"yt_downloader/history"
+	"yt_downloader/metadata"
+	"yt_downloader/utils"
+)
+
+// BackfillOptions controls fetching subtitles for existing videos
+type BackfillOptions struct {
+	Subtitles     SubtitleOptions // languages, format, source, post-processing
+	UseHistory    bool            // resolve video IDs from download history
+	SearchByTitle bool            // last resort: search YouTube by file name
+	DryRun        bool            // only report what would be fetched
+}
+
+// BackfillItem is a video file and what is missing for it
+type BackfillItem struct {
+	VideoPath string
+	VideoID   string
+	IDSource  string // history, filename, info.json, tags, search
+	Missing   []string
+}
+
+// bracketIDPattern matches yt-dlp's default "Title [VIDEO_ID].ext" naming
+var bracketIDPattern = regexp.MustCompile(`\[([A-Za-z0-9_-]{11})\]$`)
+
+// FindBackfillCandidates scans a folder for videos lacking requested languages
+func FindBackfillCandidates(ctx context.Context, folder string, options BackfillOptions) ([]BackfillItem, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, fmt.Errorf("folder read error: %v", err)
+	}
+
+	var historyByName map[string]string
+	if options.UseHistory {
+		historyByName = historyURLsByFileName()
+	}
+
+	var items []BackfillItem
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(entry.Name()), "."))
+		if !utils.ContainsString(videoExtensions, ext) {
+			continue
+		}
+
+		base := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		videoPath := filepath.Join(folder, entry.Name())
+
+		existing, err := FindSubtitleFiles(folder, base)
+		if err != nil {
+			return nil, err
+		}
+		missing := missingLanguages(options.Subtitles, existing)
+		if len(missing) == 0 {
+			continue
+		}
+
+		id, source := resolveVideoID(ctx, videoPath, base, historyByName, options.SearchByTitle)
+		items = append(items, BackfillItem{
+			VideoPath: videoPath,
+			VideoID:   id,
+			IDSource:  source,
+			Missing:   missing,
+		})
+	}
+
+	return items, nil
+}
+
+// Backfill fetches missing subtitles for every video in folder, naming them
+// after the video file and optionally embedding them. It stops when ctx is
+// cancelled.
+func Backfill(ctx context.Context, folder string, options BackfillOptions) error {
+	items, err := FindBackfillCandidates(ctx, folder, options)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		fmt.Println("✅ All videos already have the requested subtitles")
+		return nil
+	}
+
+	fmt.Printf("📋 Videos missing subtitles: %d\n", len(items))
+
+	fetched, unresolved, failed := 0, 0, 0
+	for i, item := range items {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		name := filepath.Base(item.VideoPath)
+		fmt.Printf("\n📝 %d/%d: %s\n", i+1, len(items), name)
+
+		if item.VideoID == "" {
+			fmt.Println("   ⚠ Video ID not found, skipping")
+			unresolved++
+			continue
+		}
+		fmt.Printf("   🔗 %s (from %s), missing: %s\n", item.VideoID, item.IDSource, strings.Join(item.Missing, ", "))
+
+		if options.DryRun {
+			continue
+		}
+
+		if err := backfillItem(ctx, item, options.Subtitles); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			fmt.Printf("   ⚠ Error: %v\n", err)
+			failed++
+			continue
+		}
+		fetched++
+	}
+
+	fmt.Printf("\n🎉 Backfill completed! Fetched: %d, no ID: %d, failed: %d\n", fetched, unresolved, failed)
+	return nil
+}
+
+// backfillItem downloads missing languages next to the video
+func backfillItem(ctx context.Context, item BackfillItem, options SubtitleOptions) error {
+	folder := filepath.Dir(item.VideoPath)
+	base := strings.TrimSuffix(filepath.Base(item.VideoPath), filepath.Ext(item.VideoPath))
+	url := "https://www.youtube.com/watch?v=" + item.VideoID
+
+	before, err := FindSubtitleFiles(folder, base)
+	if err != nil {
+		return err
+	}
+
+	// Embedding is done below, only for the new files
+	fetchOptions := options
+	fetchOptions.DownloadAll = false
+	fetchOptions.Languages = item.Missing
+	if err := DownloadSubtitlesOnly(ctx, url, base, folder, fetchOptions); err != nil {
+		return err
+	}
+
+	if !options.EmbedSubtitles {
+		return nil
+	}
+
+	after, err := FindSubtitleFiles(folder, base)
+	if err != nil {
+		return err
+	}
+	var added []SubtitleFile
+	for _, file := range after {
+		if !containsSubtitleFile(before, file.Path) {
+			added = append(added, file)
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	fmt.Printf("📦 Embedding %d new subtitle track(s)\n", len(added))
+	if err := EmbedSubtitles(ctx, item.VideoPath, added, options.DefaultLanguage); err != nil {
+		return err
+	}
+	if options.RemoveSidecars {
+		for _, file := range added {
+			os.Remove(file.Path)
+		}
+	}
+	return nil
+}
+
+// missingLanguages lists requested languages without a sidecar file
+func missingLanguages(options SubtitleOptions, existing []SubtitleFile) []string {
+	if options.DownloadAll {
+		if len(existing) == 0 {
+			return []string{"all"}
+		}
+		return nil
+	}
+
+	// A language counts as present when any language of its chain has a file
+	var missing []string
+	for _, lang := range options.Languages {
+		found := false
+		for _, candidate := range options.languageChain(lang) {
+			if _, ok := findLanguageFile(existing, candidate); ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, lang)
+		}
+	}
+	return missing
+}
+
+// resolveVideoID works out the source video of a downloaded file
+func resolveVideoID(ctx context.Context, videoPath, base string, historyByName map[string]string, search bool) (string, string) {
+	if url, ok := historyByName[base]; ok {
+		if id := utils.ExtractVideoID(url); id != "" {
+			return id, "history"
+		}
+	}
+	if url, ok := historyByName[filepath.Base(videoPath)]; ok {
+		if id := utils.ExtractVideoID(url); id != "" {
+			return id, "history"
+		}
+	}
+
+	if m := bracketIDPattern.FindStringSubmatch(base); m != nil {
+		return m[1], "filename"
+	}
+
+	if id := videoIDFromInfoJSON(filepath.Join(filepath.Dir(videoPath), base+".info.json")); id != "" {
+		return id, "info.json"
+	}
+
+	if id := videoIDFromTags(ctx, videoPath); id != "" {
+		return id, "tags"
+	}
+
+	if search {
+		if id := searchVideoID(ctx, base); id != "" {
+			return id, "search"
+		}
+	}
+
+	return "", ""
+}
+
+// historyURLsByFileName indexes download history by file name
+func historyURLsByFileName() map[string]string {
+	byName := map[string]string{}
+	for _, record := range history.LoadHistory() {
+		if record["file_name"] != "" && record["url"] != "" {
+			byName[record["file_name"]] = record["url"]
+		}
+	}
+	return byName
+}
+
+// videoIDFromInfoJSON reads "id" from a yt-dlp .info.json sidecar
+func videoIDFromInfoJSON(path string) string {
+	video, err := metadata.Load(path)
+	if err != nil {
+		return ""
+	}
+	return video.ID
+}
+
+// videoIDFromTags looks for the source URL in container tags
+// (yt-dlp --embed-metadata writes it to "purl" / "comment")
+func videoIDFromTags(ctx context.Context, videoPath string) string {
+	probe, err := probeMedia(ctx, videoPath)
+	if err != nil {
+		return ""
+	}
+	for key, value := range probe.Format.Tags {
+		switch strings.ToLower(key) {
+		case "purl", "comment", "description", "url":
+			if id := utils.ExtractVideoID(value); id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// searchVideoID asks yt-dlp for the first search result for a title
+func searchVideoID(ctx context.Context, title string) string {
+	ytPath := utils.GetYTDLPBinary()
+	cmd := exec.CommandContext(ctx, ytPath,
+		"--print", "id",
+		"--no-warnings",
+		"--encoding", "utf-8",
+		"ytsearch1:"+title,
+	)
+	cmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8")
+
+	output, err := utils.CommandOutputOf(cmd)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func containsSubtitleFile(files []SubtitleFile, path string) bool {
+	for _, file := range files {
+		if file.Path == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,248 @@
+package subtitles
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"yt_downloader/utils"
+)
+
+// SubtitleFile describes a subtitle file written next to a video
+type SubtitleFile struct {
+	Path     string
+	Language string
+	Ext      string
+}
+
+// videoExtensions are containers yt-dlp may produce for a video
+var videoExtensions = []string{"mp4", "mkv", "webm", "mov"}
+
+// subtitleExtensions are sidecar formats we know how to handle
+var subtitleExtensions = []string{"srt", "vtt", "ass"}
+
+// FindVideoFile locates the downloaded video for a base filename
+func FindVideoFile(folder, filename string) (string, error) {
+	for _, ext := range videoExtensions {
+		path := filepath.Join(folder, filename+"."+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("video file not found for: %s", filename)
+}
+
+// bilingualSeparator joins the languages of a merged bilingual sidecar,
+// "<filename>.ru+en.ass"
+const bilingualSeparator = "+"
+
+// FindSubtitleFiles lists sidecar subtitles named "<filename>.<lang>.<ext>".
+// Merged bilingual sidecars are our own output, not a language, and are left
+// out.
+func FindSubtitleFiles(folder, filename string) ([]SubtitleFile, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, fmt.Errorf("folder read error: %v", err)
+	}
+
+	var files []SubtitleFile
+	prefix := filename + "."
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		ext := strings.TrimPrefix(filepath.Ext(name), ".")
+		if !utils.ContainsString(subtitleExtensions, strings.ToLower(ext)) {
+			continue
+		}
+
+		// Middle part is the language code ("title.en.srt" -> "en")
+		lang := strings.TrimSuffix(strings.TrimPrefix(name, prefix), "."+ext)
+		if lang == "" || strings.Contains(lang, bilingualSeparator) {
+			continue
+		}
+
+		files = append(files, SubtitleFile{
+			Path:     filepath.Join(folder, name),
+			Language: lang,
+			Ext:      strings.ToLower(ext),
+		})
+	}
+
+	return files, nil
+}
+
+// EmbedSubtitles muxes subtitle files into the video as soft subtitle tracks.
+// Subtitle tracks already in the file are kept; when there are none, the
+// track for defaultLang (or the first one) is flagged as default.
+func EmbedSubtitles(ctx context.Context, videoPath string, subs []SubtitleFile, defaultLang string) error {
+	if len(subs) == 0 {
+		return fmt.Errorf("no subtitle files to embed")
+	}
+
+	container := strings.ToLower(strings.TrimPrefix(filepath.Ext(videoPath), "."))
+	subs = orderDefaultFirst(subs, defaultLang)
+
+	existing := 0
+	if probe, err := probeMedia(ctx, videoPath); err == nil {
+		existing = probe.countStreams("subtitle")
+	}
+
+	args := []string{"-y", "-i", videoPath}
+	for _, sub := range subs {
+		args = append(args, "-i", sub.Path)
+	}
+
+	// Every stream of the video stays, attachments like MKV cover art too
+	args = append(args, "-map", "0")
+	for i := range subs {
+		args = append(args, "-map", fmt.Sprintf("%d:0", i+1))
+	}
+	args = append(args, "-c", "copy")
+
+	for i, sub := range subs {
+		stream := fmt.Sprintf("s:%d", existing+i)
+		args = append(args, "-c:"+stream, subtitleCodec(container, sub.Ext))
+		args = append(args, "-metadata:"+stream, "language="+containerLanguage(sub.Language))
+		args = append(args, "-metadata:"+stream, "title="+LanguageName(sub.Language))
+		if i == 0 && existing == 0 {
+			args = append(args, "-disposition:"+stream, "default")
+		} else {
+			args = append(args, "-disposition:"+stream, "0")
+		}
+	}
+
+	// Write to a temp file and replace the original on success
+	tmpPath := strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + ".embed" + filepath.Ext(videoPath)
+	args = append(args, tmpPath)
+
+	cmd := exec.CommandContext(ctx, utils.GetFFmpegBinary(), args...)
+	output, err := utils.CombinedOutputOf(cmd)
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("ffmpeg error: %v\n%s", err, strings.TrimSpace(string(output)))
+	}
+
+	if err := os.Rename(tmpPath, videoPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace video file: %v", err)
+	}
+
+	return nil
+}
+
+// mediaProbe is the part of ffprobe's JSON output we use
+type mediaProbe struct {
+	Streams []struct {
+		CodecType string            `json:"codec_type"`
+		Tags      map[string]string `json:"tags"`
+	} `json:"streams"`
+	Format struct {
+		Tags map[string]string `json:"tags"`
+	} `json:"format"`
+}
+
+// probeMedia reads stream and container info with ffprobe
+func probeMedia(ctx context.Context, path string) (*mediaProbe, error) {
+	cmd := exec.CommandContext(ctx, utils.GetFFprobeBinary(),
+		"-v", "quiet",
+		"-print_format", "json",
+		"-show_format", "-show_streams",
+		path,
+	)
+	output, err := utils.CommandOutputOf(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe error: %v", err)
+	}
+
+	var probe mediaProbe
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("ffprobe JSON parse error: %v", err)
+	}
+	return &probe, nil
+}
+
+// countStreams counts streams of a codec type (video, audio, subtitle)
+func (p *mediaProbe) countStreams(codecType string) int {
+	count := 0
+	for _, stream := range p.Streams {
+		if stream.CodecType == codecType {
+			count++
+		}
+	}
+	return count
+}
+
+// embedDownloadedSubtitles embeds sidecars of a finished download
+func embedDownloadedSubtitles(ctx context.Context, folder, filename string, options SubtitleOptions) error {
+	videoPath, err := FindVideoFile(folder, filename)
+	if err != nil {
+		return err
+	}
+
+	subs, err := FindSubtitleFiles(folder, filename)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		fmt.Println("⚠ No subtitle files found to embed")
+		return nil
+	}
+
+	defaultLang := options.DefaultLanguage
+	if defaultLang == "" && len(options.Languages) > 0 {
+		defaultLang = options.Languages[0]
+	}
+
+	fmt.Printf("📦 Embedding %d subtitle track(s) into %s\n", len(subs), filepath.Base(videoPath))
+	if err := EmbedSubtitles(ctx, videoPath, subs, defaultLang); err != nil {
+		return err
+	}
+
+	if options.RemoveSidecars {
+		for _, sub := range subs {
+			if err := os.Remove(sub.Path); err != nil {
+				fmt.Printf("⚠ Failed to remove %s: %v\n", sub.Path, err)
+			}
+		}
+	}
+
+	fmt.Println("✅ Subtitles embedded")
+	return nil
+}
+
+// subtitleCodec picks the subtitle codec supported by the target container
+func subtitleCodec(container, subExt string) string {
+	switch container {
+	case "mp4", "mov":
+		return "mov_text"
+	case "webm":
+		return "webvtt"
+	default: // mkv keeps srt/ass as is
+		if subExt == "vtt" {
+			return "srt"
+		}
+		return "copy"
+	}
+}
+
+// orderDefaultFirst moves the default language track to the front
+func orderDefaultFirst(subs []SubtitleFile, defaultLang string) []SubtitleFile {
+	ordered := make([]SubtitleFile, 0, len(subs))
+	for _, sub := range subs {
+		if sub.Language == defaultLang {
+			ordered = append(ordered, sub)
+		}
+	}
+	for _, sub := range subs {
+		if sub.Language != defaultLang {
+			ordered = append(ordered, sub)
+		}
+	}
+	return ordered
+}
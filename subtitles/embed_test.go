@@ -0,0 +1,117 @@
+package subtitles
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"yt_downloader/subformat"
+)
+
+func TestFindSubtitleFilesSkipsBilingualMerge(t *testing.T) {
+	folder := t.TempDir()
+	for _, name := range []string{
+		"Video.mp4",
+		"Video.ru.ass",
+		"Video.en-US.vtt",
+		"Video.ru+en.ass", // mergeBilingualSubtitles output
+		"Video.info.json",
+		"Video 2.en.srt",
+	} {
+		if err := os.WriteFile(filepath.Join(folder, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := FindSubtitleFiles(folder, "Video")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var languages []string
+	for _, file := range files {
+		languages = append(languages, file.Language)
+	}
+	if want := []string{"en-US", "ru"}; !reflect.DeepEqual(languages, want) {
+		t.Errorf("languages = %v, want %v", languages, want)
+	}
+}
+
+func TestSidecarsKeptByDefault(t *testing.T) {
+	if DefaultSubtitleOptions.RemoveSidecars {
+		t.Error("DefaultSubtitleOptions removes sidecars")
+	}
+
+	// Entries of old batch_queue.json files still mean what they said
+	tests := []struct {
+		data   string
+		remove bool
+	}{
+		{`{"DownloadSubtitles":true,"EmbedSubtitles":true}`, false},
+		{`{"DownloadSubtitles":true,"EmbedSubtitles":true,"KeepSidecars":true}`, false},
+		{`{"DownloadSubtitles":true,"EmbedSubtitles":true,"KeepSidecars":false}`, true},
+		{`{"DownloadSubtitles":true,"EmbedSubtitles":true,"RemoveSidecars":true}`, true},
+	}
+	for _, tt := range tests {
+		var options SubtitleOptions
+		if err := json.Unmarshal([]byte(tt.data), &options); err != nil {
+			t.Fatal(err)
+		}
+		if options.RemoveSidecars != tt.remove || !options.EmbedSubtitles {
+			t.Errorf("%s: RemoveSidecars = %v, want %v", tt.data, options.RemoveSidecars, tt.remove)
+		}
+	}
+
+	// A round trip keeps everything
+	want := SubtitleOptions{
+		DownloadSubtitles: true,
+		SubtitleFormat:    "ass",
+		Languages:         []string{"uk"},
+		Fallbacks:         map[string][]string{"uk": {"ru"}},
+		RemoveSidecars:    true,
+		Cleanup:           subformat.DefaultCleanupOptions,
+	}
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got SubtitleOptions
+	if err := json.Unmarshal(data, &got); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, %v; want %+v", got, err, want)
+	}
+}
+
+func TestConvertDownloadedSubtitlesKeepsOneFilePerLanguage(t *testing.T) {
+	folder := t.TempDir()
+	vtt := "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHello\n"
+	srt := "1\n00:00:01,000 --> 00:00:02,000\nHello\n"
+	for name, data := range map[string]string{
+		"Video.en.vtt": vtt, // the requested format is there too
+		"Video.en.srt": srt,
+		"Video.ru.vtt": vtt, // only VTT: converted
+	} {
+		if err := os.WriteFile(filepath.Join(folder, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := convertDownloadedSubtitles(folder, "Video", "srt"); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := FindSubtitleFiles(folder, "Video")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file.Path))
+	}
+	if want := []string{"Video.en.srt", "Video.ru.srt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("subtitle files = %v, want %v", names, want)
+	}
+	if data, _ := os.ReadFile(filepath.Join(folder, "Video.en.srt")); string(data) != srt {
+		t.Error("the downloaded SRT was overwritten")
+	}
+}
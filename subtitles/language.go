@@ -0,0 +1,293 @@
+package subtitles
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"yt_downloader/metadata"
+	"yt_downloader/utils"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// origSuffix marks YouTube's untranslated auto captions ("en-orig")
+const origSuffix = "-orig"
+
+// bibliographicCodes are ISO 639-2/B codes that differ from the
+// terminology codes x/text returns; containers traditionally use /B
+var bibliographicCodes = map[string]string{
+	"sqi": "alb",
+	"hye": "arm",
+	"eus": "baq",
+	"mya": "bur",
+	"zho": "chi",
+	"ces": "cze",
+	"nld": "dut",
+	"fra": "fre",
+	"kat": "geo",
+	"deu": "ger",
+	"ell": "gre",
+	"isl": "ice",
+	"mkd": "mac",
+	"mri": "mao",
+	"msa": "may",
+	"fas": "per",
+	"ron": "rum",
+	"slk": "slo",
+	"bod": "tib",
+	"cym": "wel",
+}
+
+// ParseLanguage parses a BCP-47 code as used by YouTube ("en-US", "pt-BR",
+// "zh-Hans", "iw", "en-orig")
+func ParseLanguage(code string) (language.Tag, error) {
+	code = strings.TrimSpace(code)
+	if strings.HasSuffix(strings.ToLower(code), origSuffix) {
+		code = code[:len(code)-len(origSuffix)]
+	}
+
+	tag, err := language.Parse(code)
+	if err != nil {
+		return language.Und, fmt.Errorf("invalid language code: %q", code)
+	}
+	if base, _ := tag.Base(); base.String() == "und" {
+		return language.Und, fmt.Errorf("invalid language code: %q", code)
+	}
+	return tag, nil
+}
+
+// LanguageName returns an English display name for a language code
+func LanguageName(code string) string {
+	tag, err := ParseLanguage(code)
+	if err != nil {
+		return strings.ToUpper(code) // fallback
+	}
+
+	name := display.English.Tags().Name(tag)
+	if name == "" {
+		name = strings.ToUpper(code)
+	}
+	if strings.HasSuffix(strings.ToLower(code), origSuffix) {
+		name += " (original)"
+	}
+	return name
+}
+
+// containerLanguage converts a language code to ISO 639-2 used by containers
+func containerLanguage(code string) string {
+	tag, err := ParseLanguage(code)
+	if err != nil {
+		return "und" // undetermined
+	}
+
+	base, _ := tag.Base()
+	iso3 := base.ISO3()
+	if b, exists := bibliographicCodes[iso3]; exists {
+		return b
+	}
+	return iso3
+}
+
+// MatchLanguage picks the best of the available codes for a wanted one.
+// An exact match wins; otherwise any variant of the same language is
+// accepted ("en" matches "en-US" and "en-orig"), preferring the same region
+// and then the order of available (manual subtitles come first).
+// Scripts must agree when the wanted code names a script or region, so
+// "zh-Hans" never matches "zh-TW".
+func MatchLanguage(want string, available []string) (string, bool) {
+	for _, code := range available {
+		if strings.EqualFold(code, want) {
+			return code, true
+		}
+	}
+
+	wantTag, err := ParseLanguage(want)
+	if err != nil {
+		return "", false
+	}
+	wantBase, _ := wantTag.Base()
+	wantScript, scriptConf := wantTag.Script()
+	wantRegion, regionConf := wantTag.Region()
+	strict := scriptConf == language.Exact || regionConf == language.Exact
+
+	best, bestScore := "", 0
+	for _, code := range available {
+		tag, err := ParseLanguage(code)
+		if err != nil {
+			continue
+		}
+		if base, _ := tag.Base(); base != wantBase {
+			continue
+		}
+		if script, _ := tag.Script(); strict && script != wantScript {
+			continue
+		}
+
+		score := 1
+		if region, conf := tag.Region(); regionConf == language.Exact && conf == language.Exact && region == wantRegion {
+			score = 2
+		}
+		if score > bestScore {
+			best, bestScore = code, score
+		}
+	}
+
+	return best, bestScore > 0
+}
+
+// ParseLanguageList parses a comma-separated list of languages where each
+// entry may be a fallback chain: "uk>ru>en,de" wants Ukrainian (or Russian,
+// or English when that is missing too) and German
+func ParseLanguageList(input string) ([]string, map[string][]string, error) {
+	var languages []string
+	fallbacks := map[string][]string{}
+
+	input = strings.ReplaceAll(input, "→", ">")
+	for _, entry := range strings.Split(input, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		var chain []string
+		for _, code := range strings.Split(entry, ">") {
+			tag, err := ParseLanguage(code)
+			if err != nil {
+				return nil, nil, err
+			}
+			code = strings.TrimSpace(code)
+			// Keep "-orig" as typed, normalize the rest ("en_us" -> "en-US")
+			if !strings.HasSuffix(strings.ToLower(code), origSuffix) {
+				code = tag.String()
+			}
+			chain = append(chain, code)
+		}
+
+		if utils.ContainsString(languages, chain[0]) {
+			continue
+		}
+		languages = append(languages, chain[0])
+		if len(chain) > 1 {
+			fallbacks[chain[0]] = chain[1:]
+		}
+	}
+
+	if len(languages) == 0 {
+		return nil, nil, fmt.Errorf("no language codes given")
+	}
+	return languages, fallbacks, nil
+}
+
+// FormatLanguageList is the inverse of ParseLanguageList
+func FormatLanguageList(languages []string, fallbacks map[string][]string) string {
+	entries := make([]string, 0, len(languages))
+	for _, lang := range languages {
+		entries = append(entries, strings.Join(append([]string{lang}, fallbacks[lang]...), ">"))
+	}
+	return strings.Join(entries, ",")
+}
+
+// languageChain returns a language followed by its fallbacks
+func (o SubtitleOptions) languageChain(lang string) []string {
+	return append([]string{lang}, o.Fallbacks[lang]...)
+}
+
+// ResolveLanguages maps requested languages and their fallback chains onto
+// the subtitle codes a video actually has, so "en" downloads "en-US" and
+// "uk>ru" downloads Russian when there are no Ukrainian subtitles
+func ResolveLanguages(ctx context.Context, url string, options SubtitleOptions) SubtitleOptions {
+	if !options.DownloadSubtitles || options.DownloadAll || len(options.Languages) == 0 {
+		return options
+	}
+
+	video, err := metadata.Fetch(ctx, url)
+	if err != nil {
+		fmt.Printf("⚠ Could not check available subtitles, using codes as is: %v\n", err)
+		return options
+	}
+
+	available, translated := availableLanguages(video, options.CaptionSource)
+	return resolveLanguages(options, available, translated)
+}
+
+// resolveLanguages does the matching for ResolveLanguages. Auto-translated
+// captions only count once the whole chain has no original subtitles, so
+// "uk>ru>en" still falls back to real Russian subtitles.
+func resolveLanguages(options SubtitleOptions, available, translated []string) SubtitleOptions {
+	resolved := map[string]string{}
+	var languages []string
+	for _, lang := range options.Languages {
+		code, ok := matchChain(options.languageChain(lang), available)
+		note := ""
+		if !ok {
+			code, ok = matchChain(options.languageChain(lang), translated)
+			note = ", auto-translated"
+		}
+		if ok {
+			if code != lang || note != "" {
+				fmt.Printf("🌐 %s -> %s (%s%s)\n", lang, code, LanguageName(code), note)
+			}
+			resolved[lang] = code
+			if !utils.ContainsString(languages, code) {
+				languages = append(languages, code)
+			}
+		}
+		if _, ok := resolved[lang]; !ok {
+			fmt.Printf("⚠ No subtitles for %s\n", strings.Join(options.languageChain(lang), " → "))
+		}
+	}
+
+	// Nothing matched: let yt-dlp report it with the original codes
+	if len(languages) == 0 {
+		return options
+	}
+
+	options.Languages = languages
+	options.Fallbacks = nil
+	if code, ok := resolved[options.DefaultLanguage]; ok {
+		options.DefaultLanguage = code
+	}
+	if len(options.BilingualLanguages) == 2 {
+		pair := make([]string, 2)
+		for i, lang := range options.BilingualLanguages {
+			pair[i] = lang
+			if code, ok := resolved[lang]; ok {
+				pair[i] = code
+			}
+		}
+		options.BilingualLanguages = pair
+	}
+	return options
+}
+
+// matchChain returns the first language of a chain found in available
+func matchChain(chain, available []string) (string, bool) {
+	for _, candidate := range chain {
+		if code, ok := MatchLanguage(candidate, available); ok {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// availableLanguages lists subtitle codes for the chosen caption source,
+// manual subtitles first, and separately the auto-translated captions
+func availableLanguages(video *metadata.Video, source string) (available, translated []string) {
+	if source != CaptionsAuto {
+		for _, sub := range collectSubtitles(video.Subtitles, false) {
+			available = append(available, sub.Language)
+		}
+	}
+	if source == CaptionsAuto || source == CaptionsFallback {
+		for _, sub := range collectSubtitles(video.AutomaticCaptions, true) {
+			switch {
+			case utils.ContainsString(available, sub.Language):
+			case sub.Translated:
+				translated = append(translated, sub.Language)
+			default:
+				available = append(available, sub.Language)
+			}
+		}
+	}
+	return available, translated
+}
@@ -0,0 +1,251 @@
+package subtitles
+
+import (
+	"reflect"
+	"testing"
+	"yt_downloader/metadata"
+)
+
+func TestParseLanguageList(t *testing.T) {
+	tests := []struct {
+		input     string
+		languages []string
+		fallbacks map[string][]string
+	}{
+		{"en", []string{"en"}, map[string][]string{}},
+		{"uk>ru>en,de", []string{"uk", "de"}, map[string][]string{"uk": {"ru", "en"}}},
+		{" ru , en ", []string{"ru", "en"}, map[string][]string{}},
+		{"uk→ru", []string{"uk"}, map[string][]string{"uk": {"ru"}}},
+		{"en_us,pt-br", []string{"en-US", "pt-BR"}, map[string][]string{}},
+		{"en-orig>en", []string{"en-orig"}, map[string][]string{"en-orig": {"en"}}},
+		{"zh-hans,,", []string{"zh-Hans"}, map[string][]string{}},
+		{"ru,ru>en", []string{"ru"}, map[string][]string{}}, // first entry wins
+	}
+	for _, tt := range tests {
+		languages, fallbacks, err := ParseLanguageList(tt.input)
+		if err != nil {
+			t.Errorf("ParseLanguageList(%q) error: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(languages, tt.languages) || !reflect.DeepEqual(fallbacks, tt.fallbacks) {
+			t.Errorf("ParseLanguageList(%q) = %v %v, want %v %v", tt.input, languages, fallbacks, tt.languages, tt.fallbacks)
+		}
+		if back := FormatLanguageList(languages, fallbacks); back == "" {
+			t.Errorf("FormatLanguageList(%v) is empty", languages)
+		}
+	}
+
+	for _, input := range []string{"", " , ", "english!", "uk>", "en>xx-123456789"} {
+		if _, _, err := ParseLanguageList(input); err == nil {
+			t.Errorf("ParseLanguageList(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+func TestFormatLanguageListRoundTrip(t *testing.T) {
+	input := "uk>ru>en,de,pt-BR>pt"
+	languages, fallbacks, err := ParseLanguageList(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := FormatLanguageList(languages, fallbacks); got != input {
+		t.Errorf("FormatLanguageList = %q, want %q", got, input)
+	}
+}
+
+func TestMatchLanguage(t *testing.T) {
+	tests := []struct {
+		want      string
+		available []string
+		match     string
+		ok        bool
+	}{
+		// Exact match wins, case-insensitively
+		{"en", []string{"en-US", "en"}, "en", true},
+		{"EN-us", []string{"en-GB", "en-US"}, "en-US", true},
+		// Any variant of the language, in the order given
+		{"en", []string{"de", "en-GB", "en-US"}, "en-GB", true},
+		{"en", []string{"en-orig"}, "en-orig", true},
+		// Same region is preferred over the order, another region of the
+		// same script is still accepted
+		{"pt-BR", []string{"pt", "pt-br"}, "pt-br", true},
+		{"pt-BR", []string{"pt-PT"}, "pt-PT", true},
+		{"en-GB", []string{"en-US", "en-GB-oxendict"}, "en-GB-oxendict", true},
+		// A region only accepts the same script: zh-TW is Traditional
+		{"zh-Hans", []string{"zh-TW", "zh-Hant"}, "", false},
+		{"zh-Hans", []string{"zh-TW", "zh-CN"}, "zh-CN", true},
+		{"zh-Hant", []string{"zh-CN", "zh-HK"}, "zh-HK", true},
+		{"sr-Latn", []string{"sr"}, "", false},
+		// Old and new codes of the same language
+		{"he", []string{"iw"}, "iw", true},
+		// No match at all
+		{"uk", []string{"ru", "en"}, "", false},
+		{"en", nil, "", false},
+		{"not a code", []string{"en"}, "", false},
+	}
+	for _, tt := range tests {
+		match, ok := MatchLanguage(tt.want, tt.available)
+		if match != tt.match || ok != tt.ok {
+			t.Errorf("MatchLanguage(%q, %v) = %q, %v; want %q, %v", tt.want, tt.available, match, ok, tt.match, tt.ok)
+		}
+	}
+}
+
+func TestResolveLanguages(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    SubtitleOptions
+		available  []string
+		translated []string
+		want       SubtitleOptions
+	}{
+		{
+			name: "first language of the chain found",
+			options: SubtitleOptions{
+				Languages: []string{"uk", "de"},
+				Fallbacks: map[string][]string{"uk": {"ru", "en"}},
+			},
+			available: []string{"en", "ru", "de-DE"},
+			want:      SubtitleOptions{Languages: []string{"ru", "de-DE"}},
+		},
+		{
+			name: "last fallback",
+			options: SubtitleOptions{
+				Languages: []string{"uk"},
+				Fallbacks: map[string][]string{"uk": {"ru", "en"}},
+			},
+			available: []string{"en-US"},
+			want:      SubtitleOptions{Languages: []string{"en-US"}},
+		},
+		{
+			name: "default and bilingual languages follow",
+			options: SubtitleOptions{
+				Languages:          []string{"en", "ru"},
+				DefaultLanguage:    "en",
+				BilingualLanguages: []string{"ru", "en"},
+			},
+			available: []string{"ru", "en-GB"},
+			want: SubtitleOptions{
+				Languages:          []string{"en-GB", "ru"},
+				DefaultLanguage:    "en-GB",
+				BilingualLanguages: []string{"ru", "en-GB"},
+			},
+		},
+		{
+			name: "two chains ending on the same code",
+			options: SubtitleOptions{
+				Languages: []string{"uk", "be"},
+				Fallbacks: map[string][]string{"uk": {"ru"}, "be": {"ru"}},
+			},
+			available: []string{"ru"},
+			want:      SubtitleOptions{Languages: []string{"ru"}},
+		},
+		{
+			name: "original subtitles anywhere in the chain beat a translation",
+			options: SubtitleOptions{
+				Languages: []string{"uk"},
+				Fallbacks: map[string][]string{"uk": {"ru", "en"}},
+			},
+			available:  []string{"en"},
+			translated: []string{"uk", "ru"},
+			want:       SubtitleOptions{Languages: []string{"en"}},
+		},
+		{
+			name: "translation once the chain has nothing else",
+			options: SubtitleOptions{
+				Languages: []string{"uk", "de"},
+				Fallbacks: map[string][]string{"uk": {"ru"}},
+			},
+			available:  []string{"de"},
+			translated: []string{"en", "ru", "uk"},
+			want:       SubtitleOptions{Languages: []string{"uk", "de"}},
+		},
+		{
+			name: "nothing found keeps the codes for yt-dlp to report",
+			options: SubtitleOptions{
+				Languages: []string{"uk"},
+				Fallbacks: map[string][]string{"uk": {"ru"}},
+			},
+			available: []string{"en"},
+			want: SubtitleOptions{
+				Languages: []string{"uk"},
+				Fallbacks: map[string][]string{"uk": {"ru"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := resolveLanguages(tt.options, tt.available, tt.translated); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: resolveLanguages = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestContainerLanguage(t *testing.T) {
+	tests := map[string]string{
+		"en":      "eng",
+		"en-US":   "eng",
+		"en-orig": "eng",
+		"ru":      "rus",
+		"uk":      "ukr",
+		"iw":      "heb",
+		// ISO 639-2/B where it differs from /T
+		"de":      "ger",
+		"fr":      "fre",
+		"zh-Hans": "chi",
+		"nl":      "dut",
+		"cs":      "cze",
+		"el":      "gre",
+		"fa":      "per",
+		// Not a language
+		"":      "und",
+		"xx!!!": "und",
+	}
+	for code, want := range tests {
+		if got := containerLanguage(code); got != want {
+			t.Errorf("containerLanguage(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
+
+func TestLanguageName(t *testing.T) {
+	tests := map[string]string{
+		"en":      "English",
+		"en-orig": "English (original)",
+		"pt-BR":   "Brazilian Portuguese",
+		"!!":      "!!",
+	}
+	for code, want := range tests {
+		if got := LanguageName(code); got != want {
+			t.Errorf("LanguageName(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
+
+func TestAvailableLanguagesSeparatesTranslations(t *testing.T) {
+	video := &metadata.Video{
+		Subtitles: map[string][]metadata.SubtitleTrack{
+			"de": {{Ext: "vtt", URL: "https://www.youtube.com/api/timedtext?lang=de"}},
+		},
+		AutomaticCaptions: map[string][]metadata.SubtitleTrack{
+			"en": {{Ext: "vtt", URL: "https://www.youtube.com/api/timedtext?lang=en&kind=asr"}},
+			"de": {{Ext: "vtt", URL: "https://www.youtube.com/api/timedtext?lang=en&kind=asr&tlang=de"}},
+			"uk": {{Ext: "vtt", URL: "https://www.youtube.com/api/timedtext?lang=en&kind=asr&tlang=uk"}},
+		},
+	}
+
+	tests := []struct {
+		source     string
+		available  []string
+		translated []string
+	}{
+		{CaptionsManual, []string{"de"}, nil},
+		{CaptionsAuto, []string{"en"}, []string{"de", "uk"}},
+		{CaptionsFallback, []string{"de", "en"}, []string{"uk"}},
+	}
+	for _, tt := range tests {
+		available, translated := availableLanguages(video, tt.source)
+		if !reflect.DeepEqual(available, tt.available) || !reflect.DeepEqual(translated, tt.translated) {
+			t.Errorf("%s: availableLanguages = %v, %v; want %v, %v", tt.source, available, translated, tt.available, tt.translated)
+		}
+	}
+}
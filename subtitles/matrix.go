@@ -0,0 +1,208 @@
+package subtitles
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"yt_downloader/utils"
+)
+
+// Availability marks used in the matrix cells
+const (
+	AvailableManual     = "M" // uploaded by the author
+	AvailableAuto       = "A" // auto-generated in the original language
+	AvailableTranslated = "T" // only as an auto-translation
+	AvailableNone       = "-"
+)
+
+// AvailabilityRow holds the subtitle languages of one video
+type AvailabilityRow struct {
+	URL       string
+	Subtitles []SubtitleInfo
+	Err       error
+}
+
+// AvailabilityMatrix is a video × language overview of subtitles
+type AvailabilityMatrix struct {
+	Languages []string
+	Rows      []AvailabilityRow
+}
+
+// BuildAvailabilityMatrix gathers available subtitles for every URL. With no
+// languages given, the columns are every language found in manual or
+// original auto captions. It stops early when ctx is cancelled.
+func BuildAvailabilityMatrix(ctx context.Context, urls []string, languages []string) *AvailabilityMatrix {
+	matrix := &AvailabilityMatrix{Languages: languages}
+
+	for i, url := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+		fmt.Printf("🔍 %d/%d: %s\n", i+1, len(urls), url)
+		subs, err := GetAvailableSubtitles(ctx, url)
+		if err != nil {
+			fmt.Printf("   ⚠ Error: %v\n", err)
+		}
+		matrix.Rows = append(matrix.Rows, AvailabilityRow{URL: url, Subtitles: subs, Err: err})
+	}
+
+	if len(matrix.Languages) == 0 {
+		matrix.Languages = matrix.foundLanguages()
+	}
+	return matrix
+}
+
+// foundLanguages lists languages present in any row, translations excluded
+func (m *AvailabilityMatrix) foundLanguages() []string {
+	var languages []string
+	for _, row := range m.Rows {
+		for _, sub := range row.Subtitles {
+			if !sub.Translated && !utils.ContainsString(languages, sub.Language) {
+				languages = append(languages, sub.Language)
+			}
+		}
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Cell returns the availability mark of a language for one video.
+// Regional variants count, so "en" is satisfied by "en-US".
+func (r AvailabilityRow) Cell(lang string) string {
+	manual, auto, translated := r.byKind()
+	if _, ok := MatchLanguage(lang, manual); ok {
+		return AvailableManual
+	}
+	if _, ok := MatchLanguage(lang, auto); ok {
+		return AvailableAuto
+	}
+	if _, ok := MatchLanguage(lang, translated); ok {
+		return AvailableTranslated
+	}
+	return AvailableNone
+}
+
+// Has reports whether a language is available from the caption source.
+// Unlike Cell, a manual track doesn't hide auto captions of the language.
+func (r AvailabilityRow) Has(lang, source string) bool {
+	manual, auto, translated := r.byKind()
+	if source != CaptionsAuto {
+		if _, ok := MatchLanguage(lang, manual); ok {
+			return true
+		}
+	}
+	if source == CaptionsAuto || source == CaptionsFallback {
+		if _, ok := MatchLanguage(lang, append(auto, translated...)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// byKind splits the row's languages into manual, original auto and
+// auto-translated ones
+func (r AvailabilityRow) byKind() (manual, auto, translated []string) {
+	for _, sub := range r.Subtitles {
+		switch {
+		case !sub.Auto:
+			manual = append(manual, sub.Language)
+		case sub.Translated:
+			translated = append(translated, sub.Language)
+		default:
+			auto = append(auto, sub.Language)
+		}
+	}
+	return manual, auto, translated
+}
+
+// Filter splits URLs into those having every required language (or one of
+// its fallbacks) and those lacking at least one. Videos that could not be
+// checked are kept, so a network hiccup never empties a batch.
+func (m *AvailabilityMatrix) Filter(options SubtitleOptions) (kept, dropped []string) {
+	for _, row := range m.Rows {
+		if row.Err != nil {
+			kept = append(kept, row.URL)
+			continue
+		}
+
+		complete := true
+		for _, lang := range options.Languages {
+			found := false
+			for _, candidate := range options.languageChain(lang) {
+				if row.Has(candidate, options.CaptionSource) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				complete = false
+				break
+			}
+		}
+
+		if complete {
+			kept = append(kept, row.URL)
+		} else {
+			dropped = append(dropped, row.URL)
+		}
+	}
+	return kept, dropped
+}
+
+// records returns the matrix as rows of strings with a header
+func (m *AvailabilityMatrix) records() [][]string {
+	header := append([]string{"Video"}, m.Languages...)
+	records := [][]string{header}
+	for _, row := range m.Rows {
+		record := []string{row.URL}
+		for _, lang := range m.Languages {
+			if row.Err != nil {
+				record = append(record, "?")
+				continue
+			}
+			record = append(record, row.Cell(lang))
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// Print shows the matrix as a table with a legend
+func (m *AvailabilityMatrix) Print() {
+	if len(m.Languages) == 0 {
+		fmt.Println("❌ No subtitles found")
+		return
+	}
+
+	fmt.Println("\n📊 Subtitle availability:")
+	fmt.Println(renderTable(m.records()))
+	fmt.Printf("   %s = manual, %s = auto, %s = auto-translated only, %s = none, ? = check failed\n",
+		AvailableManual, AvailableAuto, AvailableTranslated, AvailableNone)
+
+	for _, lang := range m.Languages {
+		count := 0
+		for _, row := range m.Rows {
+			if cell := row.Cell(lang); cell == AvailableManual || cell == AvailableAuto {
+				count++
+			}
+		}
+		fmt.Printf("   %s (%s): %d/%d\n", lang, LanguageName(lang), count, len(m.Rows))
+	}
+}
+
+// WriteCSV exports the matrix to a CSV file
+func (m *AvailabilityMatrix) WriteCSV(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("CSV create error: %v", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(m.records()); err != nil {
+		return fmt.Errorf("CSV write error: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package subtitles
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// testMatrix has one video per kind of availability
+func testMatrix() *AvailabilityMatrix {
+	return &AvailabilityMatrix{Rows: []AvailabilityRow{
+		{URL: "manual-en", Subtitles: []SubtitleInfo{
+			{Language: "en-US"},
+			{Language: "en", Auto: true},
+			{Language: "ru", Auto: true, Translated: true},
+		}},
+		{URL: "auto-ru", Subtitles: []SubtitleInfo{
+			{Language: "ru", Auto: true},
+			{Language: "uk", Auto: true, Translated: true},
+		}},
+		{URL: "manual-de", Subtitles: []SubtitleInfo{{Language: "de"}}},
+		{URL: "failed", Err: errors.New("network error")},
+	}}
+}
+
+func TestAvailabilityCell(t *testing.T) {
+	m := testMatrix()
+	tests := []struct {
+		row  int
+		lang string
+		want string
+	}{
+		{0, "en", AvailableManual}, // en-US satisfies en, manual beats auto
+		{0, "en-GB", AvailableManual},
+		{0, "ru", AvailableTranslated},
+		{0, "de", AvailableNone},
+		{1, "ru", AvailableAuto},
+		{1, "uk", AvailableTranslated},
+		{2, "de", AvailableManual},
+		{3, "en", AvailableNone},
+	}
+	for _, tt := range tests {
+		if got := m.Rows[tt.row].Cell(tt.lang); got != tt.want {
+			t.Errorf("%s: Cell(%s) = %s, want %s", m.Rows[tt.row].URL, tt.lang, got, tt.want)
+		}
+	}
+
+	if got := m.foundLanguages(); !reflect.DeepEqual(got, []string{"de", "en", "en-US", "ru"}) {
+		t.Errorf("foundLanguages = %v, translations must not count", got)
+	}
+}
+
+func TestAvailabilityHas(t *testing.T) {
+	m := testMatrix()
+	tests := []struct {
+		row    int
+		lang   string
+		source string
+		want   bool
+	}{
+		{0, "en", CaptionsManual, true},
+		{0, "en", CaptionsAuto, true}, // auto en is there too, under the manual track
+		{0, "ru", CaptionsManual, false},
+		{0, "ru", CaptionsAuto, true}, // translation
+		{0, "ru", CaptionsFallback, true},
+		{1, "ru", CaptionsManual, false},
+		{1, "ru", CaptionsAuto, true},
+		{1, "ru", CaptionsFallback, true},
+		{2, "de", CaptionsManual, true},
+		{2, "de", CaptionsAuto, false},
+		{2, "de", CaptionsFallback, true},
+		{2, "en", CaptionsFallback, false},
+	}
+	for _, tt := range tests {
+		if got := m.Rows[tt.row].Has(tt.lang, tt.source); got != tt.want {
+			t.Errorf("%s: Has(%s, %s) = %v, want %v", m.Rows[tt.row].URL, tt.lang, tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestAvailabilityFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		options SubtitleOptions
+		kept    []string
+		dropped []string
+	}{
+		{
+			name:    "one manual language",
+			options: SubtitleOptions{Languages: []string{"en"}, CaptionSource: CaptionsManual},
+			kept:    []string{"manual-en", "failed"},
+			dropped: []string{"auto-ru", "manual-de"},
+		},
+		{
+			name: "fallback chain",
+			options: SubtitleOptions{
+				Languages:     []string{"uk"},
+				Fallbacks:     map[string][]string{"uk": {"ru", "de"}},
+				CaptionSource: CaptionsManual,
+			},
+			kept:    []string{"manual-de", "failed"},
+			dropped: []string{"manual-en", "auto-ru"},
+		},
+		{
+			name: "fallback chain with auto captions",
+			options: SubtitleOptions{
+				Languages:     []string{"ru"},
+				Fallbacks:     map[string][]string{"ru": {"de"}},
+				CaptionSource: CaptionsFallback,
+			},
+			kept: []string{"manual-en", "auto-ru", "manual-de", "failed"},
+		},
+		{
+			name:    "every language is needed",
+			options: SubtitleOptions{Languages: []string{"en", "ru"}, CaptionSource: CaptionsAuto},
+			kept:    []string{"manual-en", "failed"},
+			dropped: []string{"auto-ru", "manual-de"},
+		},
+	}
+	for _, tt := range tests {
+		kept, dropped := testMatrix().Filter(tt.options)
+		if !reflect.DeepEqual(kept, tt.kept) || !reflect.DeepEqual(dropped, tt.dropped) {
+			t.Errorf("%s: Filter = %v / %v, want %v / %v", tt.name, kept, dropped, tt.kept, tt.dropped)
+		}
+	}
+}
+
+func TestAvailabilityRecords(t *testing.T) {
+	m := testMatrix()
+	m.Languages = []string{"en", "ru"}
+	want := [][]string{
+		{"Video", "en", "ru"},
+		{"manual-en", "M", "T"},
+		{"auto-ru", "-", "A"},
+		{"manual-de", "-", "-"},
+		{"failed", "?", "?"},
+	}
+	if got := m.records(); !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %v, want %v", got, want)
+	}
+}
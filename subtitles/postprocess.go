@@ -0,0 +1,218 @@
+package subtitles
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"yt_downloader/subformat"
+	"yt_downloader/utils"
+)
+
+// postProcessSubtitles runs the post-download steps over the sidecars of
+// a finished download: retime to the kept parts of a cut video (nil keep
+// means uncut), convert, clean up, bilingual merge, transcripts,
+// verification, then embedding (which may delete the sidecars, so it goes last).
+// A failed step doesn't stop the next ones, the step errors are returned
+// together as a utils.PostProcessingError.
+func postProcessSubtitles(ctx context.Context, url, folder, filename string, options SubtitleOptions, keep []subformat.TimeRange) (*SubtitleReport, error) {
+	var errs []error
+	if keep != nil {
+		if err := retimeDownloadedSubtitles(folder, filename, keep); err != nil {
+			errs = append(errs, fmt.Errorf("subtitle retiming error: %w", err))
+		}
+	}
+
+	if err := convertDownloadedSubtitles(folder, filename, options.SubtitleFormat); err != nil {
+		errs = append(errs, fmt.Errorf("subtitle conversion error: %w", err))
+	}
+
+	if options.Cleanup.Enabled() {
+		if err := cleanupDownloadedSubtitles(folder, filename, options.Cleanup); err != nil {
+			errs = append(errs, fmt.Errorf("subtitle cleanup error: %w", err))
+		}
+	}
+
+	if len(options.BilingualLanguages) == 2 {
+		if err := mergeBilingualSubtitles(folder, filename, options); err != nil {
+			errs = append(errs, fmt.Errorf("bilingual merge error: %w", err))
+		}
+	}
+
+	if options.TranscriptFormat != "" {
+		if err := exportTranscripts(folder, filename, url, options); err != nil {
+			errs = append(errs, fmt.Errorf("transcript export error: %w", err))
+		}
+	}
+
+	report, err := VerifySubtitles(folder, filename, options)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("subtitle verification error: %w", err))
+	}
+
+	if options.EmbedSubtitles && ctx.Err() == nil {
+		if err := embedDownloadedSubtitles(ctx, folder, filename, options); err != nil {
+			errs = append(errs, fmt.Errorf("subtitle embedding error: %w", err))
+		} else if report != nil && len(report.Files) > 0 {
+			report.Embedded = true
+		}
+	}
+
+	return report, utils.PostProcessingError(errs...)
+}
+
+// retimeDownloadedSubtitles fits sidecars to a video that had parts cut
+// out, keeping only the cues of the kept parts of the timeline
+func retimeDownloadedSubtitles(folder, filename string, keep []subformat.TimeRange) error {
+	files, err := FindSubtitleFiles(folder, filename)
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, file := range files {
+		doc, err := subformat.ParseFile(file.Path)
+		if err != nil {
+			fmt.Printf("⚠ Failed to parse %s: %v\n", filepath.Base(file.Path), err)
+			failed = append(failed, filepath.Base(file.Path))
+			continue
+		}
+
+		before := len(doc.Cues)
+		doc.Keep(keep)
+		if err := doc.WriteFile(file.Path); err != nil {
+			fmt.Printf("⚠ Failed to write %s: %v\n", filepath.Base(file.Path), err)
+			failed = append(failed, filepath.Base(file.Path))
+			continue
+		}
+		fmt.Printf("✂ Retimed %s to the cut video: %d -> %d cues\n", filepath.Base(file.Path), before, len(doc.Cues))
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("not retimed: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// convertDownloadedSubtitles converts sidecars that yt-dlp saved in another
+// format (YouTube often serves only VTT) into the requested format
+func convertDownloadedSubtitles(folder, filename, format string) error {
+	if !subformat.IsSupported(format) {
+		return fmt.Errorf("unsupported subtitle format: %s", format)
+	}
+
+	files, err := FindSubtitleFiles(folder, filename)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if file.Ext == format {
+			continue
+		}
+
+		target := strings.TrimSuffix(file.Path, "."+file.Ext) + "." + format
+		if _, err := os.Stat(target); err == nil {
+			// Requested format was downloaded as well: one file per
+			// language, or both would be embedded
+			os.Remove(file.Path)
+			continue
+		}
+
+		if err := subformat.ConvertFile(file.Path, target); err != nil {
+			fmt.Printf("⚠ Failed to convert %s: %v\n", filepath.Base(file.Path), err)
+			continue
+		}
+		os.Remove(file.Path)
+		fmt.Printf("🔄 Converted %s -> %s\n", filepath.Base(file.Path), filepath.Base(target))
+	}
+
+	return nil
+}
+
+// cleanupDownloadedSubtitles runs the cleanup pipeline over downloaded sidecars
+func cleanupDownloadedSubtitles(folder, filename string, options subformat.CleanupOptions) error {
+	files, err := FindSubtitleFiles(folder, filename)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		doc, err := subformat.ParseFile(file.Path)
+		if err != nil {
+			fmt.Printf("⚠ Failed to parse %s: %v\n", filepath.Base(file.Path), err)
+			continue
+		}
+
+		before := len(doc.Cues)
+		doc.Cleanup(options)
+		if err := doc.WriteFile(file.Path); err != nil {
+			fmt.Printf("⚠ Failed to write %s: %v\n", filepath.Base(file.Path), err)
+			continue
+		}
+		fmt.Printf("🧹 Cleaned %s: %d -> %d cues\n", filepath.Base(file.Path), before, len(doc.Cues))
+	}
+
+	return nil
+}
+
+// mergeBilingualSubtitles writes "<filename>.<primary>+<secondary>.<format>"
+// combining the two BilingualLanguages sidecars
+func mergeBilingualSubtitles(folder, filename string, options SubtitleOptions) error {
+	files, err := FindSubtitleFiles(folder, filename)
+	if err != nil {
+		return err
+	}
+
+	primaryLang, secondaryLang := options.BilingualLanguages[0], options.BilingualLanguages[1]
+	primaryFile, ok := findLanguageFile(files, primaryLang)
+	if !ok {
+		return fmt.Errorf("no %s subtitles downloaded", primaryLang)
+	}
+	secondaryFile, ok := findLanguageFile(files, secondaryLang)
+	if !ok {
+		return fmt.Errorf("no %s subtitles downloaded", secondaryLang)
+	}
+
+	primary, err := subformat.ParseFile(primaryFile.Path)
+	if err != nil {
+		return err
+	}
+	secondary, err := subformat.ParseFile(secondaryFile.Path)
+	if err != nil {
+		return err
+	}
+
+	mergeOptions := subformat.DefaultMergeOptions
+	mergeOptions.Styled = options.SubtitleFormat == subformat.FormatASS
+
+	target := filepath.Join(folder, fmt.Sprintf("%s.%s%s%s.%s",
+		filename, primaryLang, bilingualSeparator, secondaryLang, options.SubtitleFormat))
+	if err := subformat.MergeBilingual(primary, secondary, mergeOptions).WriteFile(target); err != nil {
+		return err
+	}
+
+	fmt.Printf("🌐 Bilingual subtitles: %s\n", filepath.Base(target))
+	return nil
+}
+
+// findLanguageFile picks the sidecar for a language, accepting regional
+// variants ("en" matches "en-US") when there is no exact match
+func findLanguageFile(files []SubtitleFile, lang string) (SubtitleFile, bool) {
+	languages := make([]string, len(files))
+	for i, file := range files {
+		languages[i] = file.Language
+	}
+
+	code, ok := MatchLanguage(lang, languages)
+	if !ok {
+		return SubtitleFile{}, false
+	}
+	for _, file := range files {
+		if file.Language == code {
+			return file, true
+		}
+	}
+	return SubtitleFile{}, false
+}
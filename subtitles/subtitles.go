@@ -1,44 +1,73 @@
 package subtitles
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"unicode/utf8"
+	"yt_downloader/metadata"
+	"yt_downloader/subformat"
+	"yt_downloader/transcript"
 	"yt_downloader/utils"
 )
 
-// SubtitleInfo represents subtitle info
+// SubtitleInfo describes one subtitle language available for a video
 type SubtitleInfo struct {
-	Language string `json:"language"`
-	Name     string `json:"name"`
-	Ext      string `json:"ext"`
-	URL      string `json:"url"`
+	Language   string   `json:"language"`
+	Name       string   `json:"name"`
+	Formats    []string `json:"formats"`    // vtt, srv3, json3, ...
+	Auto       bool     `json:"auto"`       // auto-generated captions
+	Translated bool     `json:"translated"` // auto-translated from the original language
 }
 
-// AudioTrackInfo represents audio track info
-type AudioTrackInfo struct {
-	Language string `json:"language"`
-	Name     string `json:"name"`
-	Ext      string `json:"ext"`
-	Quality  string `json:"quality"`
-}
-
-// VideoMetadata contains video metadata
-type VideoMetadata struct {
-	Title              string           `json:"title"`
-	AvailableSubtitles []SubtitleInfo   `json:"subtitles"`
-	AudioTracks        []AudioTrackInfo `json:"formats"`
-}
+// Caption sources for SubtitleOptions.CaptionSource
+const (
+	CaptionsManual   = "manual"   // only subtitles uploaded by the author
+	CaptionsAuto     = "auto"     // only auto-generated captions
+	CaptionsFallback = "fallback" // manual, auto-generated when a language has none
+)
 
 // SubtitleOptions controls subtitle download
 type SubtitleOptions struct {
 	DownloadSubtitles bool
-	SubtitleFormat    string   // srt, vtt, ass
-	Languages         []string // языки для скачивания
-	DownloadAll       bool     // скачать все доступные
+	SubtitleFormat    string              // srt, vtt, ass
+	CaptionSource     string              // manual, auto, fallback
+	Languages         []string            // языки для скачивания
+	Fallbacks         map[string][]string // запасные языки: "uk" -> ["ru", "en"]
+	DownloadAll       bool                // скачать все доступные
+	EmbedSubtitles    bool                // встроить субтитры в контейнер (mp4/mkv)
+	RemoveSidecars    bool                // удалить файлы субтитров после встраивания
+	DefaultLanguage   string              // язык дорожки по умолчанию (пусто = первый из Languages)
+
+	Cleanup            subformat.CleanupOptions // очистка субтитров после скачивания
+	BilingualLanguages []string                 // два языка для объединённого файла: основной, второй
+
+	TranscriptFormat     string // txt, md, json (пусто = без транскрипта)
+	TranscriptTimestamps bool   // метки времени в транскрипте
+}
+
+// UnmarshalJSON also reads options saved before RemoveSidecars replaced
+// KeepSidecars, so "KeepSidecars": false in an old batch_queue.json still
+// removes the files
+func (o *SubtitleOptions) UnmarshalJSON(data []byte) error {
+	type plain SubtitleOptions
+	var saved struct {
+		plain
+		KeepSidecars *bool
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	*o = SubtitleOptions(saved.plain)
+	if saved.KeepSidecars != nil && !*saved.KeepSidecars {
+		o.RemoveSidecars = true
+	}
+	return nil
 }
 
 // AudioTrackOptions controls audio track preferences
@@ -52,8 +81,10 @@ var (
 	DefaultSubtitleOptions = SubtitleOptions{
 		DownloadSubtitles: false,
 		SubtitleFormat:    "srt",
+		CaptionSource:     CaptionsManual,
 		Languages:         []string{"ru", "en", "uk"},
 		DownloadAll:       false,
+		EmbedSubtitles:    false,
 	}
 
 	DefaultAudioOptions = AudioTrackOptions{
@@ -62,126 +93,64 @@ var (
 	}
 )
 
-// GetVideoMetadata retrieves complete metadata for a video
-func GetVideoMetadata(url string) (*VideoMetadata, error) {
-	ytPath := filepath.Join("bin", "yt-dlp.exe")
-
-	// Ask yt-dlp for JSON metadata
-	cmd := exec.Command(ytPath,
-		"--dump-json",         // выводить JSON
-		"--no-warnings",       // без предупреждений
-		"--encoding", "utf-8", // кодировка
-		url,
-	)
-
-	cmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8")
-
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("metadata retrieval error: %v", err)
-	}
-
-	var metadata VideoMetadata
-	if err := json.Unmarshal(output, &metadata); err != nil {
-		return nil, fmt.Errorf("JSON parse error: %v", err)
-	}
-
-	return &metadata, nil
-}
-
 // GetAvailableSubtitles returns available subtitles for a video
-func GetAvailableSubtitles(url string) ([]SubtitleInfo, error) {
-	ytPath := filepath.Join("bin", "yt-dlp.exe")
-
-	cmd := exec.Command(ytPath,
-		"--list-subs", // список субтитров
-		"--no-warnings",
-		url,
-	)
-
-	cmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8")
-
-	output, err := cmd.Output()
+func GetAvailableSubtitles(ctx context.Context, url string) ([]SubtitleInfo, error) {
+	video, err := metadata.Fetch(ctx, url)
 	if err != nil {
-		return nil, fmt.Errorf("subtitles list retrieval error: %v", err)
+		return nil, fmt.Errorf("subtitles list retrieval error: %w", err)
 	}
 
-	// Parse --list-subs output
-	return parseSubtitlesList(string(output)), nil
+	return subtitlesFromMetadata(video), nil
 }
 
-// parseSubtitlesList parses --list-subs output
-func parseSubtitlesList(output string) []SubtitleInfo {
+// subtitlesFromMetadata converts yt-dlp subtitle maps into a sorted list:
+// manual subtitles first, then auto captions, each ordered by language code
+func subtitlesFromMetadata(video *metadata.Video) []SubtitleInfo {
 	var subtitles []SubtitleInfo
-	lines := strings.Split(output, "\n")
-
-	var inSubtitlesSection bool
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		// Seek the subtitles section
-		if strings.Contains(line, "Available subtitles") {
-			inSubtitlesSection = true
-			continue
-		}
+	subtitles = append(subtitles, collectSubtitles(video.Subtitles, false)...)
+	subtitles = append(subtitles, collectSubtitles(video.AutomaticCaptions, true)...)
+	return subtitles
+}
 
-		// Skip automatic captions section
-		if strings.Contains(line, "Available automatic captions") {
-			inSubtitlesSection = false
+// collectSubtitles builds SubtitleInfo entries from one yt-dlp subtitle map
+func collectSubtitles(tracks map[string][]metadata.SubtitleTrack, auto bool) []SubtitleInfo {
+	languages := make([]string, 0, len(tracks))
+	for lang := range tracks {
+		// live chat replay is not a subtitle track
+		if lang == "live_chat" {
 			continue
 		}
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
 
-		if !inSubtitlesSection || line == "" {
-			continue
+	var subtitles []SubtitleInfo
+	for _, lang := range languages {
+		info := SubtitleInfo{
+			Language: lang,
+			Name:     LanguageName(lang),
+			Auto:     auto,
 		}
 
-		// Parse subtitle row (format: "ru vtt")
-		parts := strings.Fields(line)
-		if len(parts) >= 2 {
-			subtitle := SubtitleInfo{
-				Language: parts[0],
-				Name:     getLanguageName(parts[0]),
-				Ext:      parts[1],
+		for _, track := range tracks[lang] {
+			if track.Name != "" {
+				info.Name = track.Name
+			}
+			if track.Ext != "" && !utils.ContainsString(info.Formats, track.Ext) {
+				info.Formats = append(info.Formats, track.Ext)
+			}
+			// Translated caption URLs carry the target language
+			if auto && strings.Contains(track.URL, "tlang=") {
+				info.Translated = true
 			}
-			subtitles = append(subtitles, subtitle)
 		}
+
+		subtitles = append(subtitles, info)
 	}
 
 	return subtitles
 }
 
-// getLanguageName returns language display name
-func getLanguageName(code string) string {
-	languages := map[string]string{
-		"ru":   "Russian",
-		"en":   "English",
-		"uk":   "Ukrainian",
-		"de":   "Deutsch",
-		"fr":   "Français",
-		"es":   "Español",
-		"it":   "Italiano",
-		"pt":   "Português",
-		"ja":   "日本語",
-		"ko":   "한국어",
-		"zh":   "中文",
-		"ar":   "العربية",
-		"hi":   "हिन्दी",
-		"pl":   "Polski",
-		"tr":   "Türkçe",
-		"nl":   "Nederlands",
-		"sv":   "Svenska",
-		"no":   "Norsk",
-		"da":   "Dansk",
-		"fi":   "Suomi",
-		"orig": "Original",
-	}
-
-	if name, exists := languages[code]; exists {
-		return name
-	}
-	return strings.ToUpper(code) // fallback
-}
-
 // PromptSubtitleOptions prompts user for subtitle options
 func PromptSubtitleOptions() SubtitleOptions {
 	var options SubtitleOptions
@@ -200,6 +169,21 @@ func PromptSubtitleOptions() SubtitleOptions {
 		return options
 	}
 
+	return promptSubtitleDetails(true)
+}
+
+// PromptSubtitleOnlyOptions prompts for subtitle settings when no video is
+// downloaded (no embedding question)
+func PromptSubtitleOnlyOptions() SubtitleOptions {
+	fmt.Println("\n📝 === SUBTITLES SETTINGS ===")
+	return promptSubtitleDetails(false)
+}
+
+// promptSubtitleDetails asks for format, source, languages and post-processing
+func promptSubtitleDetails(withVideo bool) SubtitleOptions {
+	var options SubtitleOptions
+	var choice string
+
 	options.DownloadSubtitles = true
 
 	// Choose subtitle format
@@ -219,6 +203,23 @@ func PromptSubtitleOptions() SubtitleOptions {
 		options.SubtitleFormat = "srt"
 	}
 
+	// Choose caption source
+	fmt.Println("\nChoose caption source:")
+	fmt.Println("1 - Manual subtitles only (default)")
+	fmt.Println("2 - Auto-generated captions only")
+	fmt.Println("3 - Manual, fall back to auto-generated per language")
+	fmt.Print("Your choice: ")
+	fmt.Scanln(&choice)
+
+	switch choice {
+	case "2":
+		options.CaptionSource = CaptionsAuto
+	case "3":
+		options.CaptionSource = CaptionsFallback
+	default:
+		options.CaptionSource = CaptionsManual
+	}
+
 	// Choose languages
 	fmt.Println("\nChoose subtitle languages:")
 	fmt.Println("1 - Russian and English")
@@ -237,29 +238,131 @@ func PromptSubtitleOptions() SubtitleOptions {
 	case "4":
 		options.Languages = []string{"en"}
 	case "5":
-		fmt.Print("Enter language codes comma-separated (e.g.: ru,en,de,fr): ")
-		var langInput string
-		fmt.Scanln(&langInput)
-		options.Languages = strings.Split(strings.ReplaceAll(langInput, " ", ""), ",")
+		for {
+			fmt.Println("Enter language codes comma-separated, > marks fallbacks")
+			fmt.Print("(e.g.: ru,en,pt-BR or uk>ru>en,de): ")
+			var langInput string
+			if _, err := fmt.Scanln(&langInput); err != nil || strings.TrimSpace(langInput) == "" {
+				// Blank line or end of input: don't ask forever
+				fmt.Println("⚠ No languages entered, using Russian and English")
+				options.Languages = []string{"ru", "en"}
+				break
+			}
+
+			languages, fallbacks, err := ParseLanguageList(langInput)
+			if err != nil {
+				fmt.Printf("⚠ %v\n", err)
+				continue
+			}
+			options.Languages = languages
+			options.Fallbacks = fallbacks
+			break
+		}
 	default:
 		options.Languages = []string{"ru", "en"}
 	}
 
-	fmt.Printf("✅ Subtitles: format %s\n", options.SubtitleFormat)
+	// Embed into the container
+	if withVideo {
+		fmt.Println("\nEmbed subtitles into the video file?")
+		fmt.Println("1 - No, keep separate files (default)")
+		fmt.Println("2 - Yes, embed and keep separate files")
+		fmt.Println("3 - Yes, embed and delete separate files")
+		fmt.Print("Your choice: ")
+		fmt.Scanln(&choice)
+
+		switch choice {
+		case "2":
+			options.EmbedSubtitles = true
+		case "3":
+			options.EmbedSubtitles = true
+			options.RemoveSidecars = true
+		}
+	}
+
+	// Bilingual merge of the first two languages
+	if !options.DownloadAll && len(options.Languages) >= 2 {
+		primary, secondary := options.Languages[0], options.Languages[1]
+		fmt.Println("\nCreate a combined bilingual subtitle file?")
+		fmt.Println("1 - No (default)")
+		fmt.Printf("2 - Yes: %s with %s on a second line\n", primary, secondary)
+		fmt.Printf("3 - Yes: %s with %s on a second line (swapped)\n", secondary, primary)
+		fmt.Print("Your choice: ")
+		fmt.Scanln(&choice)
+
+		switch choice {
+		case "2":
+			options.BilingualLanguages = []string{primary, secondary}
+		case "3":
+			options.BilingualLanguages = []string{secondary, primary}
+		}
+	}
+
+	// Cleanup filters
+	fmt.Println("\nClean up subtitles after download?")
+	fmt.Println("1 - No (default)")
+	fmt.Println("2 - Yes: strip [Music]/>> markers/tags, merge short cues, fix overlaps")
+	fmt.Print("Your choice: ")
+	fmt.Scanln(&choice)
+
+	if choice == "2" {
+		options.Cleanup = subformat.DefaultCleanupOptions
+	}
+
+	// Transcript export
+	fmt.Println("\nExport a transcript from the subtitles?")
+	fmt.Println("1 - No (default)")
+	fmt.Println("2 - Plain text (.txt)")
+	fmt.Println("3 - Markdown (.md)")
+	fmt.Println("4 - JSON (.json)")
+	fmt.Print("Your choice: ")
+	fmt.Scanln(&choice)
+
+	switch choice {
+	case "2":
+		options.TranscriptFormat = transcript.FormatText
+	case "3":
+		options.TranscriptFormat = transcript.FormatMarkdown
+	case "4":
+		options.TranscriptFormat = transcript.FormatJSON
+	}
+
+	if options.TranscriptFormat != "" {
+		fmt.Println("Include timestamps?")
+		fmt.Println("1 - Yes (default)")
+		fmt.Println("2 - No")
+		fmt.Print("Your choice: ")
+		fmt.Scanln(&choice)
+		options.TranscriptTimestamps = choice != "2"
+	}
+
+	fmt.Printf("✅ Subtitles: format %s, source: %s\n", options.SubtitleFormat, options.CaptionSource)
 	if options.DownloadAll {
 		fmt.Println("📝 Languages: ALL AVAILABLE")
 	} else {
-		fmt.Printf("📝 Languages: %v\n", options.Languages)
+		fmt.Printf("📝 Languages: %s\n", FormatLanguageList(options.Languages, options.Fallbacks))
+	}
+	if options.EmbedSubtitles {
+		fmt.Println("📦 Subtitles will be embedded into the video")
+	}
+	if options.Cleanup.Enabled() {
+		fmt.Println("🧹 Subtitles will be cleaned up")
+	}
+	if len(options.BilingualLanguages) == 2 {
+		fmt.Printf("🌐 Bilingual file: %s + %s\n", options.BilingualLanguages[0], options.BilingualLanguages[1])
+	}
+	if options.TranscriptFormat != "" {
+		fmt.Printf("📄 Transcript: %s\n", options.TranscriptFormat)
 	}
 
 	return options
 }
 
 // ShowAvailableSubtitles prints available subtitles for a video
-func ShowAvailableSubtitles(url string) {
+func ShowAvailableSubtitles(ctx context.Context, url string) {
 	fmt.Println("\n🔍 Checking available subtitles...")
 
-	subtitles, err := GetAvailableSubtitles(url)
+	subtitles, err := GetAvailableSubtitles(ctx, url)
 	if err != nil {
 		fmt.Printf("⚠ Error: %v\n", err)
 		return
@@ -270,13 +373,87 @@ func ShowAvailableSubtitles(url string) {
 		return
 	}
 
-	fmt.Printf("✅ Subtitles found: %d\n", len(subtitles))
-	fmt.Println("📝 Available languages:")
+	var listed, translated []SubtitleInfo
+	manualCount := 0
+	for _, sub := range subtitles {
+		if sub.Translated {
+			translated = append(translated, sub)
+			continue
+		}
+		if !sub.Auto {
+			manualCount++
+		}
+		listed = append(listed, sub)
+	}
+
+	fmt.Printf("✅ Subtitles found: %d (manual: %d, auto: %d)\n",
+		len(subtitles), manualCount, len(subtitles)-manualCount)
 
+	if len(listed) > 0 {
+		fmt.Println(renderSubtitleTable(listed))
+	}
+
+	if len(translated) > 0 {
+		codes := make([]string, 0, len(translated))
+		for _, sub := range translated {
+			codes = append(codes, sub.Language)
+		}
+		fmt.Printf("🌐 Auto-translated (%d): %s\n", len(translated), strings.Join(codes, ", "))
+	}
+}
+
+// renderSubtitleTable formats subtitles as an aligned text table
+func renderSubtitleTable(subtitles []SubtitleInfo) string {
+	header := []string{"Language", "Name", "Type", "Formats"}
+	rows := [][]string{header}
 	for _, sub := range subtitles {
-		fmt.Printf("   • %s (%s) - формат: %s\n",
-			sub.Name, sub.Language, strings.ToUpper(sub.Ext))
+		kind := "manual"
+		if sub.Auto {
+			kind = "auto"
+		}
+		rows = append(rows, []string{sub.Language, sub.Name, kind, strings.Join(sub.Formats, ", ")})
+	}
+
+	return renderTable(rows)
+}
+
+// renderTable formats rows as an aligned text table; the first row is the header
+func renderTable(rows [][]string) string {
+	if len(rows) == 0 {
+		return ""
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var b strings.Builder
+	for r, row := range rows {
+		b.WriteString("   ")
+		for i, cell := range row {
+			b.WriteString(cell)
+			if i < len(row)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2))
+			}
+		}
+		if r == 0 {
+			total := 0
+			for _, w := range widths {
+				total += w + 2
+			}
+			b.WriteString("\n   " + strings.Repeat("-", total-2))
+		}
+		if r < len(rows)-1 {
+			b.WriteString("\n")
+		}
 	}
+
+	return b.String()
 }
 
 // BuildSubtitleArgs builds yt-dlp flags for subtitles
@@ -285,14 +462,22 @@ func BuildSubtitleArgs(options SubtitleOptions) []string {
 		return []string{}
 	}
 
-	args := []string{
-		"--write-subs", // regular subtitles
-		"--sub-format", options.SubtitleFormat,
+	var args []string
+	switch options.CaptionSource {
+	case CaptionsAuto:
+		args = append(args, "--write-auto-subs") // auto-generated captions
+	case CaptionsFallback:
+		// yt-dlp prefers manual subtitles and uses auto captions
+		// only for languages without them
+		args = append(args, "--write-subs", "--write-auto-subs")
+	default:
+		args = append(args, "--write-subs") // regular subtitles
 	}
+	args = append(args, "--sub-format", options.SubtitleFormat)
 
 	if options.DownloadAll {
-		// use --all-subs to fetch all languages
-		args = append(args, "--all-subs")
+		// "all" works for both manual and auto captions
+		args = append(args, "--sub-langs", "all")
 	} else {
 		// languages list format
 		langs := strings.Join(options.Languages, ",")
@@ -305,9 +490,16 @@ func BuildSubtitleArgs(options SubtitleOptions) []string {
 	return args
 }
 
-// DownloadWithSubtitles downloads a video with subtitles
-func DownloadWithSubtitles(url, filename, folder string, videoFormat string, subOptions SubtitleOptions) error {
-	ytPath := filepath.Join("bin", "yt-dlp.exe")
+// EditFunc changes a downloaded video before its subtitles are processed,
+// e.g. cuts parts out of it, and returns the parts of the original timeline
+// that were kept; nil leaves the subtitle timing as it is
+type EditFunc func(videoPath string) []subformat.TimeRange
+
+// DownloadWithSubtitles downloads a video with subtitles. edit, if set, runs
+// between the download and the subtitle post-processing, so sidecars,
+// transcripts and embedded tracks follow its cuts.
+func DownloadWithSubtitles(ctx context.Context, url, filename, folder string, videoFormat string, subOptions SubtitleOptions, edit EditFunc) error {
+	ytPath := utils.GetYTDLPBinary()
 	outPath := filepath.Join(folder, filename+".%(ext)s")
 
 	// More precise 1080p selector
@@ -329,6 +521,7 @@ func DownloadWithSubtitles(url, filename, folder string, videoFormat string, sub
 	}
 
 	// Add subtitle args
+	subOptions = ResolveLanguages(ctx, url, subOptions)
 	subArgs := BuildSubtitleArgs(subOptions)
 	args = append(args, subArgs...)
 
@@ -344,29 +537,105 @@ func DownloadWithSubtitles(url, filename, folder string, videoFormat string, sub
 	fmt.Printf("🎯 Video format: %s\n", finalVideoFormat)
 	if subOptions.DownloadSubtitles {
 		if subOptions.DownloadAll {
-			fmt.Printf("📝 Subtitles: %s (ALL LANGUAGES)\n", subOptions.SubtitleFormat)
+			fmt.Printf("📝 Subtitles: %s (ALL LANGUAGES), source: %s\n",
+				subOptions.SubtitleFormat, subOptions.CaptionSource)
 		} else {
-			fmt.Printf("📝 Subtitles: %s, languages: %v\n",
-				subOptions.SubtitleFormat, subOptions.Languages)
+			fmt.Printf("📝 Subtitles: %s, languages: %v, source: %s\n",
+				subOptions.SubtitleFormat, subOptions.Languages, subOptions.CaptionSource)
 		}
 	}
 
 	// Show full command for debugging
 	fmt.Printf("🔧 Command: %s %s\n", ytPath, strings.Join(args, " "))
 
-	cmd := exec.Command(ytPath, args...)
+	cmd := exec.CommandContext(ctx, ytPath, args...)
 	cmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
 
-	err := cmd.Run()
+	err := utils.RunCommand(cmd)
+	if err != nil {
+		if utils.Interrupted(ctx, url, folder, filename) {
+			return context.Cause(ctx)
+		}
+		return fmt.Errorf("download error: %w", err)
+	}
+
+	var keep []subformat.TimeRange
+	if edit != nil {
+		if videoPath, err := FindVideoFile(folder, filename); err == nil {
+			keep = edit(videoPath)
+		}
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+	}
+
+	report, err := postProcessSubtitles(ctx, url, folder, filename, subOptions, keep)
+	if report != nil {
+		report.Print()
+	}
+	recordSubtitleHistory(url, filename, report)
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
 	if err != nil {
-		return fmt.Errorf("download error: %v", err)
+		return err
 	}
 
-	fmt.Printf("✅ Done! Check output folder for subtitle files (.%s)\n",
-		subOptions.SubtitleFormat)
+	fmt.Println("✅ Done!")
+
+	utils.PlayBeepShort()
+	return nil
+}
+
+// DownloadSubtitlesOnly fetches subtitle files without the video
+func DownloadSubtitlesOnly(ctx context.Context, url, filename, folder string, subOptions SubtitleOptions) error {
+	ytPath := utils.GetYTDLPBinary()
+	outPath := filepath.Join(folder, filename+".%(ext)s")
+
+	// Nothing to embed into
+	subOptions.DownloadSubtitles = true
+	subOptions.EmbedSubtitles = false
+	subOptions = ResolveLanguages(ctx, url, subOptions)
+
+	args := []string{
+		"--skip-download", // subtitles only
+		"-o", outPath,
+		"--no-warnings",
+	}
+	args = append(args, BuildSubtitleArgs(subOptions)...)
+	args = append(args, "--retries", "3", url)
+
+	fmt.Printf("📝 Downloading subtitles only: %s\n", filename)
+
+	cmd := exec.CommandContext(ctx, ytPath, args...)
+	cmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8")
+
+	if err := utils.RunCommand(cmd); err != nil {
+		if utils.Interrupted(ctx, url, folder, filename) {
+			return context.Cause(ctx)
+		}
+		return fmt.Errorf("subtitles download error: %w", err)
+	}
+
+	report, err := postProcessSubtitles(ctx, url, folder, filename, subOptions, nil)
+	if report == nil {
+		return fmt.Errorf("could not verify subtitle files: %w", err)
+	}
+	report.Print()
+	recordSubtitleHistory(url, filename, report)
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+
+	valid := report.Valid()
+	if len(valid) == 0 {
+		return fmt.Errorf("no subtitles found for the requested languages")
+	}
+	if err != nil {
+		return err
+	}
 
+	fmt.Printf("✅ Subtitles saved: %d file(s)\n", len(valid))
 	utils.PlayBeepShort()
 	return nil
 }
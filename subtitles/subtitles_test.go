@@ -0,0 +1,59 @@
+package subtitles
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// withStdin runs fn with input as standard input
+func withStdin(t *testing.T, input string, fn func()) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	stdin := os.Stdin
+	os.Stdin = file
+	defer func() { os.Stdin = stdin }()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("prompt did not return for input %q", input)
+	}
+}
+
+func TestPromptCustomLanguages(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		languages []string
+		fallbacks map[string][]string
+	}{
+		{"end of input", "1\n1\n5\n", []string{"ru", "en"}, nil},
+		{"blank line", "1\n1\n5\n\n", []string{"ru", "en"}, nil},
+		{"invalid, then valid", "1\n1\n5\nenglish!\nuk>ru\n", []string{"uk"}, map[string][]string{"uk": {"ru"}}},
+		{"invalid, then end of input", "1\n1\n5\nenglish!\n", []string{"ru", "en"}, nil},
+	}
+	for _, tt := range tests {
+		var options SubtitleOptions
+		withStdin(t, tt.input, func() { options = promptSubtitleDetails(false) })
+		if !reflect.DeepEqual(options.Languages, tt.languages) || !reflect.DeepEqual(options.Fallbacks, tt.fallbacks) {
+			t.Errorf("%s: languages %v %v, want %v %v", tt.name, options.Languages, options.Fallbacks, tt.languages, tt.fallbacks)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package subtitles
+
+import (
+	"fmt"
+	"path/filepath"
+	"yt_downloader/transcript"
+	"yt_downloader/utils"
+)
+
+// exportTranscripts writes a transcript next to every downloaded subtitle file
+func exportTranscripts(folder, filename, url string, options SubtitleOptions) error {
+	if !transcript.IsSupported(options.TranscriptFormat) {
+		return fmt.Errorf("unsupported transcript format: %s", options.TranscriptFormat)
+	}
+
+	files, err := FindSubtitleFiles(folder, filename)
+	if err != nil {
+		return err
+	}
+
+	transcriptOptions := transcript.Options{
+		Format:     options.TranscriptFormat,
+		Timestamps: options.TranscriptTimestamps,
+		VideoID:    utils.ExtractVideoID(url),
+		Title:      filename,
+	}
+
+	for _, file := range files {
+		output := transcript.OutputPath(file.Path, options.TranscriptFormat)
+		if err := transcript.BuildFile(file.Path, output, transcriptOptions); err != nil {
+			fmt.Printf("⚠ Transcript failed for %s: %v\n", filepath.Base(file.Path), err)
+			continue
+		}
+		fmt.Printf("📄 Transcript: %s\n", filepath.Base(output))
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package subtitles
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"yt_downloader/history"
+	"yt_downloader/subformat"
+)
+
+// ProducedSubtitle is a subtitle file found after a download
+type ProducedSubtitle struct {
+	File SubtitleFile
+	Cues int
+	Err  error // unreadable, empty or in the wrong format
+}
+
+// SubtitleReport compares the subtitle files on disk with what was requested
+type SubtitleReport struct {
+	Format   string
+	Files    []ProducedSubtitle
+	Missing  []string // requested languages without a valid file
+	Embedded bool     // files were also muxed into the video
+}
+
+// VerifySubtitles finds the sidecars written for filename, checks that they
+// parse and have cues, and lists requested languages that have no valid file
+func VerifySubtitles(folder, filename string, options SubtitleOptions) (*SubtitleReport, error) {
+	files, err := FindSubtitleFiles(folder, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SubtitleReport{Format: options.SubtitleFormat}
+	var valid []SubtitleFile
+	for _, file := range files {
+		produced := ProducedSubtitle{File: file}
+
+		if options.SubtitleFormat != "" && file.Ext != options.SubtitleFormat {
+			produced.Err = fmt.Errorf("format %s, requested %s", file.Ext, options.SubtitleFormat)
+		} else if doc, err := subformat.ParseFile(file.Path); err != nil {
+			produced.Err = err
+		} else if produced.Cues = len(doc.Cues); produced.Cues == 0 {
+			produced.Err = fmt.Errorf("no cues")
+		}
+
+		if produced.Err == nil {
+			valid = append(valid, file)
+		}
+		report.Files = append(report.Files, produced)
+	}
+
+	if !options.DownloadAll {
+		for _, lang := range options.Languages {
+			found := false
+			for _, candidate := range options.languageChain(lang) {
+				if _, ok := findLanguageFile(valid, candidate); ok {
+					found = true
+					break
+				}
+			}
+			if !found {
+				report.Missing = append(report.Missing, lang)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// Valid returns the files that passed verification
+func (r *SubtitleReport) Valid() []SubtitleFile {
+	var files []SubtitleFile
+	for _, produced := range r.Files {
+		if produced.Err == nil {
+			files = append(files, produced.File)
+		}
+	}
+	return files
+}
+
+// Print lists produced files and missing languages
+func (r *SubtitleReport) Print() {
+	if len(r.Files) == 0 {
+		fmt.Println("❌ No subtitle files were produced")
+	} else {
+		fmt.Printf("📝 Subtitle files: %d\n", len(r.Files))
+	}
+
+	for _, produced := range r.Files {
+		name := filepath.Base(produced.File.Path)
+		if produced.Err != nil {
+			fmt.Printf("   ⚠ %s: %v\n", name, produced.Err)
+			continue
+		}
+		fmt.Printf("   ✅ %s (%s, %d cues)\n", name, LanguageName(produced.File.Language), produced.Cues)
+	}
+
+	if len(r.Missing) > 0 {
+		fmt.Printf("⚠ Requested but missing: %s\n", strings.Join(r.Missing, ", "))
+	}
+	if r.Embedded {
+		fmt.Println("📦 Subtitles embedded into the video")
+	}
+}
+
+// historyDetails converts the report into history record fields
+func (r *SubtitleReport) historyDetails() map[string]string {
+	var produced []string
+	for _, file := range r.Valid() {
+		produced = append(produced, filepath.Base(file.Path))
+	}
+
+	details := map[string]string{
+		"subtitles":         strings.Join(produced, ", "),
+		"subtitles_missing": strings.Join(r.Missing, ", "),
+	}
+	if r.Embedded {
+		details["subtitles_embedded"] = "true"
+	}
+	return details
+}
+
+// recordSubtitleHistory saves a download and its subtitle files to history
+func recordSubtitleHistory(url, filename string, report *SubtitleReport) {
+	if report == nil {
+		return
+	}
+	downloadTime := time.Now().Format("2006-01-02 15:04:05")
+	history.SaveToHistoryWithDetails(url, filename, downloadTime, report.historyDetails())
+}
@@ -0,0 +1,114 @@
+package subtitles
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const validSRT = "1\n00:00:01,000 --> 00:00:02,000\nHello\n\n2\n00:00:02,000 --> 00:00:03,000\nWorld\n"
+
+// writeSubtitles creates files in a temp folder
+func writeSubtitles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	folder := t.TempDir()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(folder, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return folder
+}
+
+func TestVerifySubtitles(t *testing.T) {
+	folder := writeSubtitles(t, map[string]string{
+		"Video.mp4":       "",
+		"Video.en-US.srt": validSRT,
+		"Video.ru.srt":    "",                                                 // empty
+		"Video.de.vtt":    "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHallo\n", // extra, wrong format
+	})
+
+	tests := []struct {
+		name    string
+		options SubtitleOptions
+		missing []string
+	}{
+		{"regional variant counts", SubtitleOptions{SubtitleFormat: "srt", Languages: []string{"en"}}, nil},
+		{"empty and wrong-format files count as missing", SubtitleOptions{SubtitleFormat: "srt", Languages: []string{"en", "ru", "de"}}, []string{"ru", "de"}},
+		{"a fallback file satisfies the chain", SubtitleOptions{SubtitleFormat: "srt", Languages: []string{"uk"}, Fallbacks: map[string][]string{"uk": {"ru", "en"}}}, nil},
+		{"nothing in the chain", SubtitleOptions{SubtitleFormat: "srt", Languages: []string{"uk"}, Fallbacks: map[string][]string{"uk": {"ru"}}}, []string{"uk"}},
+		{"all languages: nothing is missing", SubtitleOptions{SubtitleFormat: "srt", DownloadAll: true, Languages: []string{"ja"}}, nil},
+	}
+	for _, tt := range tests {
+		report, err := VerifySubtitles(folder, "Video", tt.options)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(report.Missing, tt.missing) {
+			t.Errorf("%s: Missing = %v, want %v", tt.name, report.Missing, tt.missing)
+		}
+	}
+
+	report, _ := VerifySubtitles(folder, "Video", SubtitleOptions{SubtitleFormat: "srt"})
+	errs := map[string]bool{}
+	for _, produced := range report.Files {
+		errs[produced.File.Language] = produced.Err != nil
+		if produced.File.Language == "en-US" && produced.Cues != 2 {
+			t.Errorf("en-US cues = %d, want 2", produced.Cues)
+		}
+	}
+	if want := map[string]bool{"de": true, "en-US": false, "ru": true}; !reflect.DeepEqual(errs, want) {
+		t.Errorf("file errors = %v, want %v", errs, want)
+	}
+
+	var valid []string
+	for _, file := range report.Valid() {
+		valid = append(valid, filepath.Base(file.Path))
+	}
+	if !reflect.DeepEqual(valid, []string{"Video.en-US.srt"}) {
+		t.Errorf("Valid() = %v", valid)
+	}
+
+	// Without a requested format any parseable file is fine
+	report, _ = VerifySubtitles(folder, "Video", SubtitleOptions{Languages: []string{"de"}})
+	if len(report.Missing) != 0 {
+		t.Errorf("no format requested: Missing = %v", report.Missing)
+	}
+}
+
+func TestVerifySubtitlesNothingProduced(t *testing.T) {
+	folder := writeSubtitles(t, map[string]string{"Video.mp4": ""})
+	report, err := VerifySubtitles(folder, "Video", SubtitleOptions{SubtitleFormat: "srt", Languages: []string{"en", "ru"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Files) != 0 || !reflect.DeepEqual(report.Missing, []string{"en", "ru"}) {
+		t.Errorf("report = %+v", report)
+	}
+}
+
+func TestSubtitleHistoryDetails(t *testing.T) {
+	report := &SubtitleReport{
+		Files: []ProducedSubtitle{
+			{File: SubtitleFile{Path: filepath.Join("dir", "Video.en.srt"), Language: "en"}, Cues: 3},
+			{File: SubtitleFile{Path: filepath.Join("dir", "Video.ru.srt"), Language: "ru"}, Err: os.ErrNotExist},
+			{File: SubtitleFile{Path: filepath.Join("dir", "Video.de.srt"), Language: "de"}, Cues: 1},
+		},
+		Missing:  []string{"ru", "uk"},
+		Embedded: true,
+	}
+	want := map[string]string{
+		"subtitles":          "Video.en.srt, Video.de.srt",
+		"subtitles_missing":  "ru, uk",
+		"subtitles_embedded": "true",
+	}
+	if got := report.historyDetails(); !reflect.DeepEqual(got, want) {
+		t.Errorf("historyDetails = %v, want %v", got, want)
+	}
+
+	report.Embedded = false
+	if _, ok := report.historyDetails()["subtitles_embedded"]; ok {
+		t.Error("subtitles_embedded written for a report without embedding")
+	}
+}
@@ -0,0 +1,308 @@
+package thumbnail
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"yt_downloader/metadata"
+	"yt_downloader/utils"
+)
+
+// Image formats for Options.Format
+const (
+	FormatJPEG = "jpg"
+	FormatPNG  = "png"
+)
+
+// Options controls what happens with a video's thumbnail
+type Options struct {
+	Save   bool   // keep the image next to the media file
+	Format string // jpg, png ("" = as served, usually WebP)
+	Square bool   // center-crop to 1:1 (audio cover art)
+	Embed  bool   // embed as cover art / attachment
+}
+
+// Enabled reports whether the thumbnail is needed at all
+func (o Options) Enabled() bool {
+	return o.Save || o.Embed
+}
+
+// client downloads thumbnail images
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// Process fetches the thumbnail for a downloaded media file and applies
+// the options: convert, crop, embed, then remove it unless Save is set.
+// Cancelling ctx kills yt-dlp or ffmpeg; the media file is left as it was.
+func Process(ctx context.Context, url, mediaPath string, options Options) error {
+	if !options.Enabled() {
+		return nil
+	}
+
+	folder := filepath.Dir(mediaPath)
+	base := strings.TrimSuffix(filepath.Base(mediaPath), filepath.Ext(mediaPath))
+
+	imagePath, err := Download(ctx, url, folder, base)
+	if err != nil {
+		return err
+	}
+
+	format := options.Format
+	if format == "" && options.Embed && strings.HasSuffix(imagePath, ".webp") {
+		format = FormatJPEG // containers don't take WebP cover art
+	}
+	if format != "" || options.Square {
+		converted, err := Convert(ctx, imagePath, format, options.Square)
+		if err != nil {
+			return err
+		}
+		imagePath = converted
+	}
+
+	if options.Embed {
+		if err := Embed(ctx, mediaPath, imagePath); err != nil {
+			return err
+		}
+		fmt.Println("🖼 Thumbnail embedded")
+	}
+
+	if options.Save {
+		fmt.Printf("🖼 Thumbnail saved: %s\n", filepath.Base(imagePath))
+	} else {
+		os.Remove(imagePath)
+	}
+	return nil
+}
+
+// Download saves the best thumbnail as "<filename>.<ext>" and returns its path
+func Download(ctx context.Context, url, folder, filename string) (string, error) {
+	video, err := metadata.Fetch(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("thumbnail download error: %w", err)
+	}
+	thumb, ok := video.BestThumbnail()
+	if !ok {
+		return "", fmt.Errorf("no thumbnail available")
+	}
+	return Fetch(ctx, thumb, filepath.Join(folder, filename))
+}
+
+// Fetch downloads a thumbnail image to "<base>.<ext>", with the extension
+// taken from its URL, and returns the path
+func Fetch(ctx context.Context, thumb metadata.Thumbnail, base string) (string, error) {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(strings.SplitN(thumb.URL, "?", 2)[0]), "."))
+	if ext == "" || ext == "jpeg" {
+		ext = FormatJPEG
+	}
+	target := base + "." + ext
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, thumb.URL, nil)
+	if err != nil {
+		return "", fmt.Errorf("thumbnail download error: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("thumbnail download error: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("thumbnail download error: HTTP %d", resp.StatusCode)
+	}
+
+	// Write to a temp file so a failed download leaves nothing behind
+	tmpPath := target + ".part"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return "", fmt.Errorf("thumbnail create error: %v", err)
+	}
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("thumbnail write error: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("thumbnail write error: %v", err)
+	}
+	if err := os.Rename(tmpPath, target); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("thumbnail rename error: %v", err)
+	}
+	return target, nil
+}
+
+// Convert re-encodes an image to format (jpg/png, "" keeps the extension)
+// and optionally center-crops it to a square. The source is replaced.
+func Convert(ctx context.Context, imagePath, format string, square bool) (string, error) {
+	ext := strings.TrimPrefix(filepath.Ext(imagePath), ".")
+	if format == "" {
+		format = ext
+	}
+	target := strings.TrimSuffix(imagePath, filepath.Ext(imagePath)) + "." + format
+
+	// Same name as the source: write to a temp file first
+	output := target
+	if target == imagePath {
+		output = strings.TrimSuffix(imagePath, filepath.Ext(imagePath)) + ".tmp." + format
+	}
+
+	args := []string{"-y", "-i", imagePath}
+	if square {
+		args = append(args, "-vf", "crop='min(iw,ih)':'min(iw,ih)'")
+	}
+	if format == FormatJPEG {
+		args = append(args, "-q:v", "2")
+	}
+	args = append(args, "-frames:v", "1", output)
+
+	cmd := exec.CommandContext(ctx, utils.GetFFmpegBinary(), args...)
+	if out, err := utils.CombinedOutputOf(cmd); err != nil {
+		os.Remove(output)
+		return "", fmt.Errorf("thumbnail conversion error: %v\n%s", err, strings.TrimSpace(string(out)))
+	}
+
+	if output != target {
+		if err := os.Rename(output, target); err != nil {
+			return "", fmt.Errorf("thumbnail rename error: %v", err)
+		}
+	} else {
+		os.Remove(imagePath)
+	}
+	return target, nil
+}
+
+// Embed adds an image as cover art: attached picture for MP3/M4A/MP4,
+// attachment for MKV
+func Embed(ctx context.Context, mediaPath, imagePath string) error {
+	container := strings.ToLower(strings.TrimPrefix(filepath.Ext(mediaPath), "."))
+	imageExt := strings.ToLower(strings.TrimPrefix(filepath.Ext(imagePath), "."))
+
+	var args []string
+	switch container {
+	case "mp3":
+		args = []string{"-y", "-i", mediaPath, "-i", imagePath,
+			"-map", "0:a", "-map", "1:0", "-c", "copy",
+			"-id3v2_version", "3",
+			"-metadata:s:v", "title=Album cover",
+			"-metadata:s:v", "comment=Cover (front)",
+			"-disposition:v", "attached_pic",
+		}
+	case "m4a", "mp4", "mov":
+		stream := fmt.Sprintf("v:%d", countVideoStreams(ctx, mediaPath))
+		args = []string{"-y", "-i", mediaPath, "-i", imagePath,
+			"-map", "0", "-map", "1:0", "-c", "copy",
+			"-disposition:" + stream, "attached_pic",
+		}
+	case "mkv":
+		mimeType := "image/jpeg"
+		if imageExt == FormatPNG {
+			mimeType = "image/png"
+		}
+		args = []string{"-y", "-i", mediaPath, "-map", "0", "-c", "copy",
+			"-attach", imagePath,
+			"-metadata:s:t", "mimetype=" + mimeType,
+			"-metadata:s:t", "filename=cover." + imageExt,
+		}
+	default:
+		return fmt.Errorf("thumbnail embedding is not supported for .%s", container)
+	}
+
+	if imageExt != FormatJPEG && imageExt != "jpeg" && imageExt != FormatPNG {
+		return fmt.Errorf("cover art must be JPEG or PNG, got .%s", imageExt)
+	}
+
+	// Write to a temp file and replace the original on success
+	tmpPath := strings.TrimSuffix(mediaPath, filepath.Ext(mediaPath)) + ".cover" + filepath.Ext(mediaPath)
+	args = append(args, tmpPath)
+
+	cmd := exec.CommandContext(ctx, utils.GetFFmpegBinary(), args...)
+	if output, err := utils.CombinedOutputOf(cmd); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("ffmpeg error: %v\n%s", err, strings.TrimSpace(string(output)))
+	}
+
+	if err := os.Rename(tmpPath, mediaPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace media file: %v", err)
+	}
+	return nil
+}
+
+// countVideoStreams returns how many video streams a file has
+func countVideoStreams(ctx context.Context, path string) int {
+	cmd := exec.CommandContext(ctx, utils.GetFFprobeBinary(),
+		"-v", "quiet",
+		"-select_streams", "v",
+		"-show_entries", "stream=index",
+		"-of", "csv=p=0",
+		path,
+	)
+	output, err := utils.CommandOutputOf(cmd)
+	if err != nil {
+		return 0
+	}
+	return len(strings.Fields(string(output)))
+}
+
+// PromptOptions asks what to do with thumbnails; audio defaults to square
+// cover art
+func PromptOptions(forAudio bool) Options {
+	var options Options
+	var choice string
+
+	fmt.Println("\n🖼 === THUMBNAIL ===")
+	fmt.Println("What to do with the thumbnail?")
+	fmt.Println("1 - Nothing (default)")
+	fmt.Println("2 - Save as a separate image")
+	fmt.Println("3 - Embed into the file")
+	fmt.Println("4 - Save and embed")
+	fmt.Print("Your choice: ")
+	fmt.Scanln(&choice)
+
+	switch choice {
+	case "2":
+		options.Save = true
+	case "3":
+		options.Embed = true
+	case "4":
+		options.Save, options.Embed = true, true
+	default:
+		return options
+	}
+
+	fmt.Println("\nImage format:")
+	fmt.Println("1 - JPEG (default)")
+	fmt.Println("2 - PNG")
+	fmt.Println("3 - Keep original (usually WebP)")
+	fmt.Print("Your choice: ")
+	fmt.Scanln(&choice)
+
+	switch choice {
+	case "2":
+		options.Format = FormatPNG
+	case "3":
+		options.Format = ""
+	default:
+		options.Format = FormatJPEG
+	}
+
+	fmt.Println("\nCrop to a square?")
+	if forAudio {
+		fmt.Println("1 - Yes, cover art style (default)")
+		fmt.Println("2 - No")
+	} else {
+		fmt.Println("1 - No (default)")
+		fmt.Println("2 - Yes")
+	}
+	fmt.Print("Your choice: ")
+	fmt.Scanln(&choice)
+	options.Square = (choice == "2") != forAudio
+
+	return options
+}
@@ -0,0 +1,54 @@
+package thumbnail
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"yt_downloader/metadata"
+)
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.jpg" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("image " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	folder := t.TempDir()
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"/vi/abc/maxresdefault.webp", "cover.webp"},
+		{"/vi/abc/hqdefault.JPEG?sqp=x.png", "cover.jpg"},
+		{"/vi/abc/image", "cover.jpg"},
+	}
+	for _, tt := range tests {
+		got, err := Fetch(context.Background(), metadata.Thumbnail{URL: server.URL + tt.url}, filepath.Join(folder, "cover"))
+		if err != nil {
+			t.Fatalf("Fetch(%s): %v", tt.url, err)
+		}
+		if filepath.Base(got) != tt.want {
+			t.Errorf("Fetch(%s) = %s, want %s", tt.url, filepath.Base(got), tt.want)
+		}
+		os.Remove(got)
+	}
+
+	if _, err := Fetch(context.Background(), metadata.Thumbnail{URL: server.URL + "/missing.jpg"}, filepath.Join(folder, "missing")); err == nil {
+		t.Error("Fetch of a 404 succeeded")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Fetch(ctx, metadata.Thumbnail{URL: server.URL + "/a.jpg"}, filepath.Join(folder, "cancelled")); err == nil {
+		t.Error("Fetch with a cancelled context succeeded")
+	}
+	if entries, _ := os.ReadDir(folder); len(entries) != 0 {
+		t.Errorf("failed fetches left %d files", len(entries))
+	}
+}
@@ -0,0 +1,296 @@
+// Package transcript turns subtitle cues into readable text, Markdown and
+// JSON transcripts.
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"yt_downloader/subformat"
+)
+
+// Supported transcript formats
+const (
+	FormatText     = "txt"
+	FormatMarkdown = "md"
+	FormatJSON     = "json"
+)
+
+// Options controls transcript layout
+type Options struct {
+	Format         string        // txt, md, json
+	Timestamps     bool          // prefix paragraphs with their start time
+	VideoID        string        // used for youtube.com/watch?v=ID&t=... links
+	Title          string        // heading for Markdown / JSON
+	ParagraphGap   time.Duration // silence that starts a new paragraph
+	ParagraphLimit time.Duration // soft maximum paragraph length
+}
+
+// DefaultOptions are used for zero-valued fields
+var DefaultOptions = Options{
+	Format:         FormatText,
+	Timestamps:     true,
+	ParagraphGap:   2 * time.Second,
+	ParagraphLimit: 45 * time.Second,
+}
+
+// Segment is one line of speech after duplicate removal
+type Segment struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// Paragraph groups consecutive segments
+type Paragraph struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// IsSupported reports whether the transcript format is known
+func IsSupported(format string) bool {
+	switch format {
+	case FormatText, FormatMarkdown, FormatJSON:
+		return true
+	}
+	return false
+}
+
+// Segments extracts text lines from cues. YouTube auto captions repeat the
+// previous line at the top of every cue ("rolling" captions); lines already
+// emitted recently are skipped so each spoken line appears once.
+func Segments(doc *subformat.Document) []Segment {
+	var segments []Segment
+	var recent []string
+
+	for _, cue := range doc.Cues {
+		for _, line := range strings.Split(subformat.PlainText(cue.Text), "\n") {
+			line = strings.Join(strings.Fields(line), " ")
+			if line == "" || containsLine(recent, line) {
+				continue
+			}
+
+			segments = append(segments, Segment{Start: cue.Start, End: cue.End, Text: line})
+			recent = append(recent, line)
+			if len(recent) > 3 {
+				recent = recent[1:]
+			}
+		}
+	}
+
+	return segments
+}
+
+// Paragraphs groups segments by pauses and length
+func Paragraphs(segments []Segment, options Options) []Paragraph {
+	options = withDefaults(options)
+
+	var paragraphs []Paragraph
+	var current *Paragraph
+	var lastText string
+
+	for _, seg := range segments {
+		if current != nil {
+			gap := seg.Start - current.End
+			length := current.End - current.Start
+			if gap >= options.ParagraphGap ||
+				(length >= options.ParagraphLimit && endsSentence(lastText)) ||
+				length >= 2*options.ParagraphLimit {
+				paragraphs = append(paragraphs, *current)
+				current = nil
+			}
+		}
+
+		if current == nil {
+			current = &Paragraph{Start: seg.Start, End: seg.End, Text: seg.Text}
+		} else {
+			current.Text += " " + seg.Text
+			if seg.End > current.End {
+				current.End = seg.End
+			}
+		}
+		lastText = seg.Text
+	}
+
+	if current != nil {
+		paragraphs = append(paragraphs, *current)
+	}
+	return paragraphs
+}
+
+// Build renders a transcript for the subtitle document
+func Build(doc *subformat.Document, options Options) ([]byte, error) {
+	options = withDefaults(options)
+	paragraphs := Paragraphs(Segments(doc), options)
+
+	switch options.Format {
+	case FormatText:
+		return renderText(paragraphs, options), nil
+	case FormatMarkdown:
+		return renderMarkdown(paragraphs, options), nil
+	case FormatJSON:
+		return renderJSON(paragraphs, options)
+	default:
+		return nil, fmt.Errorf("unsupported transcript format: %s", options.Format)
+	}
+}
+
+// BuildFile reads a subtitle file and writes its transcript to output
+func BuildFile(input, output string, options Options) error {
+	doc, err := subformat.ParseFile(input)
+	if err != nil {
+		return err
+	}
+
+	data, err := Build(doc, options)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return fmt.Errorf("transcript write error: %v", err)
+	}
+	return nil
+}
+
+// OutputPath returns "<subtitle name>.transcript.<format>" next to the input
+func OutputPath(input, format string) string {
+	return strings.TrimSuffix(input, filepath.Ext(input)) + ".transcript." + format
+}
+
+// TimestampURL links to the video at the given offset
+func TimestampURL(videoID string, at time.Duration) string {
+	return fmt.Sprintf("https://www.youtube.com/watch?v=%s&t=%ds", videoID, int(at/time.Second))
+}
+
+// =================== Rendering ===================
+
+func renderText(paragraphs []Paragraph, options Options) []byte {
+	var b strings.Builder
+	if options.Title != "" {
+		b.WriteString(options.Title + "\n\n")
+	}
+	for _, p := range paragraphs {
+		if options.Timestamps {
+			fmt.Fprintf(&b, "[%s] ", formatClock(p.Start))
+		}
+		b.WriteString(p.Text + "\n\n")
+	}
+	return []byte(b.String())
+}
+
+func renderMarkdown(paragraphs []Paragraph, options Options) []byte {
+	var b strings.Builder
+	if options.Title != "" {
+		b.WriteString("# " + options.Title + "\n\n")
+	}
+	if options.VideoID != "" {
+		fmt.Fprintf(&b, "Source: https://www.youtube.com/watch?v=%s\n\n", options.VideoID)
+	}
+
+	for _, p := range paragraphs {
+		if options.Timestamps {
+			if options.VideoID != "" {
+				fmt.Fprintf(&b, "[%s](%s) ", formatClock(p.Start), TimestampURL(options.VideoID, p.Start))
+			} else {
+				fmt.Fprintf(&b, "**[%s]** ", formatClock(p.Start))
+			}
+		}
+		b.WriteString(p.Text + "\n\n")
+	}
+	return []byte(b.String())
+}
+
+// jsonParagraph is the JSON shape of a paragraph
+type jsonParagraph struct {
+	Start     float64 `json:"start"`
+	End       float64 `json:"end"`
+	Timestamp string  `json:"timestamp"`
+	URL       string  `json:"url,omitempty"`
+	Text      string  `json:"text"`
+}
+
+func renderJSON(paragraphs []Paragraph, options Options) ([]byte, error) {
+	out := struct {
+		VideoID    string          `json:"video_id,omitempty"`
+		Title      string          `json:"title,omitempty"`
+		URL        string          `json:"url,omitempty"`
+		Paragraphs []jsonParagraph `json:"paragraphs"`
+	}{
+		VideoID:    options.VideoID,
+		Title:      options.Title,
+		Paragraphs: []jsonParagraph{},
+	}
+	if options.VideoID != "" {
+		out.URL = "https://www.youtube.com/watch?v=" + options.VideoID
+	}
+
+	for _, p := range paragraphs {
+		jp := jsonParagraph{
+			Start:     p.Start.Seconds(),
+			End:       p.End.Seconds(),
+			Timestamp: formatClock(p.Start),
+			Text:      p.Text,
+		}
+		if options.VideoID != "" {
+			jp.URL = TimestampURL(options.VideoID, p.Start)
+		}
+		out.Paragraphs = append(out.Paragraphs, jp)
+	}
+
+	// Keep "&t=" readable instead of "\u0026t="
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(out); err != nil {
+		return nil, fmt.Errorf("transcript JSON error: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// =================== Helpers ===================
+
+// withDefaults fills zero-valued options from DefaultOptions
+func withDefaults(options Options) Options {
+	if options.Format == "" {
+		options.Format = DefaultOptions.Format
+	}
+	if options.ParagraphGap == 0 {
+		options.ParagraphGap = DefaultOptions.ParagraphGap
+	}
+	if options.ParagraphLimit == 0 {
+		options.ParagraphLimit = DefaultOptions.ParagraphLimit
+	}
+	return options
+}
+
+// formatClock formats HH:MM:SS
+func formatClock(d time.Duration) string {
+	total := int(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, total/60%60, total%60)
+}
+
+// endsSentence reports whether text ends with sentence punctuation
+func endsSentence(text string) bool {
+	for _, mark := range []string{".", "?", "!", "…"} {
+		if strings.HasSuffix(text, mark) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsLine(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package transcript
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"yt_downloader/subformat"
+)
+
+func sec(n float64) time.Duration {
+	return time.Duration(n * float64(time.Second))
+}
+
+func TestSegmentsRollingWindow(t *testing.T) {
+	doc := &subformat.Document{Cues: []subformat.Cue{
+		{Start: sec(0), End: sec(2), Text: "hello there"},
+		{Start: sec(2), End: sec(4), Text: "hello there\nhow  are you"},
+		{Start: sec(4), End: sec(6), Text: "how are you\n<c>fine</c>"},
+		{Start: sec(6), End: sec(8), Text: "fine\n\nthanks"},
+		{Start: sec(8), End: sec(9), Text: "yes"},
+		{Start: sec(9), End: sec(10), Text: "fine"}, // still in the last three lines
+		{Start: sec(10), End: sec(11), Text: "a\nb\nc"},
+		{Start: sec(11), End: sec(12), Text: "yes"}, // out of the window: said again
+	}}
+
+	var got []string
+	for _, seg := range Segments(doc) {
+		got = append(got, seg.Start.String()+" "+seg.Text)
+	}
+	want := []string{"0s hello there", "2s how are you", "4s fine", "6s thanks", "8s yes", "10s a", "10s b", "10s c", "11s yes"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Segments =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestParagraphs(t *testing.T) {
+	options := Options{ParagraphGap: 2 * time.Second, ParagraphLimit: 10 * time.Second}
+	tests := []struct {
+		name     string
+		segments []Segment
+		want     []Paragraph
+	}{
+		{
+			name: "pause and sentence end over the limit",
+			segments: []Segment{
+				{Start: sec(0), End: sec(4), Text: "One."},
+				{Start: sec(4), End: sec(8), Text: "Two"},
+				{Start: sec(8), End: sec(12), Text: "three."},
+				{Start: sec(12), End: sec(14), Text: "Four"},
+				{Start: sec(17), End: sec(18), Text: "Five"},
+			},
+			want: []Paragraph{
+				{Start: sec(0), End: sec(12), Text: "One. Two three."},
+				{Start: sec(12), End: sec(14), Text: "Four"},
+				{Start: sec(17), End: sec(18), Text: "Five"},
+			},
+		},
+		{
+			name: "no punctuation: split at twice the limit",
+			segments: []Segment{
+				{Start: sec(0), End: sec(5), Text: "a"},
+				{Start: sec(5), End: sec(10), Text: "b"},
+				{Start: sec(10), End: sec(15), Text: "c"},
+				{Start: sec(15), End: sec(20), Text: "d"},
+				{Start: sec(20), End: sec(25), Text: "e"},
+			},
+			want: []Paragraph{
+				{Start: sec(0), End: sec(20), Text: "a b c d"},
+				{Start: sec(20), End: sec(25), Text: "e"},
+			},
+		},
+		{
+			name: "overlapping segments keep the later end",
+			segments: []Segment{
+				{Start: sec(0), End: sec(6), Text: "long"},
+				{Start: sec(1), End: sec(3), Text: "short"},
+			},
+			want: []Paragraph{{Start: sec(0), End: sec(6), Text: "long short"}},
+		},
+		{name: "nothing", segments: nil, want: nil},
+	}
+	for _, tt := range tests {
+		if got := Paragraphs(tt.segments, options); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Paragraphs =\n%+v\nwant\n%+v", tt.name, got, tt.want)
+		}
+	}
+
+	// Zero options fall back to the defaults: a 3s pause is a new paragraph
+	got := Paragraphs([]Segment{{Start: 0, End: sec(1), Text: "a"}, {Start: sec(4), End: sec(5), Text: "b"}}, Options{})
+	if len(got) != 2 {
+		t.Errorf("default gap: %d paragraphs, want 2", len(got))
+	}
+}
+
+// talk has two paragraphs: at 0s and, after a pause, at 1h 2m 3s
+var talk = &subformat.Document{Cues: []subformat.Cue{
+	{Start: sec(0), End: sec(2), Text: "Hello there."},
+	{Start: sec(3723), End: sec(3725), Text: "Bye & thanks."},
+}}
+
+func TestBuildText(t *testing.T) {
+	tests := []struct {
+		options Options
+		want    string
+	}{
+		{Options{Format: FormatText, Timestamps: true, Title: "Talk"}, "Talk\n\n[00:00:00] Hello there.\n\n[01:02:03] Bye & thanks.\n\n"},
+		{Options{Format: FormatText}, "Hello there.\n\nBye & thanks.\n\n"},
+		{Options{Timestamps: true}, "[00:00:00] Hello there.\n\n[01:02:03] Bye & thanks.\n\n"}, // txt by default
+		{
+			Options{Format: FormatMarkdown, Timestamps: true, Title: "Talk", VideoID: "abc"},
+			"# Talk\n\nSource: https://www.youtube.com/watch?v=abc\n\n" +
+				"[00:00:00](https://www.youtube.com/watch?v=abc&t=0s) Hello there.\n\n" +
+				"[01:02:03](https://www.youtube.com/watch?v=abc&t=3723s) Bye & thanks.\n\n",
+		},
+		{Options{Format: FormatMarkdown, Timestamps: true}, "**[00:00:00]** Hello there.\n\n**[01:02:03]** Bye & thanks.\n\n"},
+	}
+	for _, tt := range tests {
+		data, err := Build(talk, tt.options)
+		if err != nil {
+			t.Fatalf("Build(%+v): %v", tt.options, err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("Build(%+v) =\n%q\nwant\n%q", tt.options, data, tt.want)
+		}
+	}
+
+	if _, err := Build(talk, Options{Format: "pdf"}); err == nil || IsSupported("pdf") {
+		t.Error("pdf must be unsupported")
+	}
+}
+
+func TestBuildJSON(t *testing.T) {
+	data, err := Build(talk, Options{Format: FormatJSON, Title: "Talk", VideoID: "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "&t=3723s") {
+		t.Errorf("URLs must not be HTML-escaped:\n%s", data)
+	}
+
+	var out struct {
+		VideoID    string          `json:"video_id"`
+		Title      string          `json:"title"`
+		URL        string          `json:"url"`
+		Paragraphs []jsonParagraph `json:"paragraphs"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.VideoID != "abc" || out.Title != "Talk" || out.URL != "https://www.youtube.com/watch?v=abc" {
+		t.Errorf("header = %q %q %q", out.VideoID, out.Title, out.URL)
+	}
+	want := []jsonParagraph{
+		{Start: 0, End: 2, Timestamp: "00:00:00", URL: "https://www.youtube.com/watch?v=abc&t=0s", Text: "Hello there."},
+		{Start: 3723, End: 3725, Timestamp: "01:02:03", URL: "https://www.youtube.com/watch?v=abc&t=3723s", Text: "Bye & thanks."},
+	}
+	if !reflect.DeepEqual(out.Paragraphs, want) {
+		t.Errorf("paragraphs = %+v, want %+v", out.Paragraphs, want)
+	}
+
+	// No cues: an empty list, not null
+	data, _ = Build(&subformat.Document{}, Options{Format: FormatJSON})
+	if !strings.Contains(string(data), `"paragraphs": []`) {
+		t.Errorf("empty transcript JSON:\n%s", data)
+	}
+}
+
+func TestTimestampURL(t *testing.T) {
+	tests := []struct {
+		at   time.Duration
+		want string
+	}{
+		{0, "https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=0s"},
+		{sec(59.9), "https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=59s"},
+		{sec(3723), "https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=3723s"},
+	}
+	for _, tt := range tests {
+		if got := TimestampURL("dQw4w9WgXcQ", tt.at); got != tt.want {
+			t.Errorf("TimestampURL(%s) = %q, want %q", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestBuildFile(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "Video.en.srt")
+	srt := "1\n00:00:01,000 --> 00:00:02,000\nHello\n\n2\n00:00:02,000 --> 00:00:03,000\nHello\nworld.\n"
+	if err := os.WriteFile(input, []byte(srt), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := OutputPath(input, FormatText)
+	if filepath.Base(output) != "Video.en.transcript.txt" {
+		t.Errorf("OutputPath = %s", output)
+	}
+	if err := BuildFile(input, output, Options{Format: FormatText, Timestamps: true}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(output); string(data) != "[00:00:01] Hello world.\n\n" {
+		t.Errorf("transcript = %q", data)
+	}
+
+	if err := BuildFile(filepath.Join(t.TempDir(), "missing.srt"), output, Options{}); err == nil {
+		t.Error("BuildFile of a missing input succeeded")
+	}
+}
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// kindError is a class of yt-dlp failure, matched with errors.Is
+type kindError struct {
+	kind string
+	text string
+}
+
+func (e *kindError) Error() string { return e.text }
+
+// Classes of yt-dlp failures, e.g. errors.Is(err, utils.ErrPrivate)
+var (
+	ErrUnavailable        error = &kindError{"unavailable", "video unavailable"}
+	ErrPrivate            error = &kindError{"private", "private video"}
+	ErrMembersOnly        error = &kindError{"members-only", "members-only video"}
+	ErrAgeRestricted      error = &kindError{"age-restricted", "age-restricted video"}
+	ErrGeoBlocked         error = &kindError{"geo-blocked", "video is not available in this country"}
+	ErrRateLimited        error = &kindError{"rate-limited", "rate limited by the site"}
+	ErrFormatNotAvailable error = &kindError{"format-not-available", "requested format is not available"}
+	ErrNetwork            error = &kindError{"network", "network error"}
+	ErrFFmpegMissing      error = &kindError{"ffmpeg-missing", "ffmpeg not found"}
+
+	// ErrPostProcessing marks a finished download whose later steps
+	// (cutting, thumbnail, tags...) failed; the media file is there
+	ErrPostProcessing error = &kindError{"post-processing", "post-processing failed"}
+)
+
+// PostProcessingError joins the errors of post-processing steps into one
+// matching ErrPostProcessing, or returns nil when every step succeeded
+func PostProcessingError(errs ...error) error {
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%w: %w", ErrPostProcessing, err)
+	}
+	return nil
+}
+
+// errorPatterns map yt-dlp messages to classes; the first match wins, so
+// specific reasons come before the generic "Video unavailable"
+var errorPatterns = []struct {
+	kind error
+	re   *regexp.Regexp
+}{
+	{ErrPrivate, regexp.MustCompile(`(?i)private video|video is private`)},
+	{ErrMembersOnly, regexp.MustCompile(`(?i)members[- ]only|join this channel|available to this channel's members`)},
+	{ErrAgeRestricted, regexp.MustCompile(`(?i)confirm your age|age[- ]restricted|inappropriate for some users`)},
+	{ErrGeoBlocked, regexp.MustCompile(`(?i)available in your country|geo[- ]restrict|available from your location`)},
+	{ErrRateLimited, regexp.MustCompile(`(?i)HTTP Error 429|too many requests|rate[- ]limit`)},
+	{ErrFormatNotAvailable, regexp.MustCompile(`(?i)requested format (is )?not available`)},
+	{ErrFFmpegMissing, regexp.MustCompile(`(?i)ffmpeg (is )?not (found|installed)|ffmpeg/avconv not found`)},
+	{ErrUnavailable, regexp.MustCompile(`(?i)video unavailable|has been removed|no longer available|account .* terminated|HTTP Error 404|unsupported url|not a valid url|incomplete youtube id`)},
+	{ErrNetwork, regexp.MustCompile(`(?i)HTTP Error 5\d\d|unable to download (webpage|video data|json)|urlopen error|connection (reset|refused|aborted)|timed out|name resolution|getaddrinfo|network is unreachable|incompleteread`)},
+}
+
+// YtDlpError is a failed yt-dlp run. Kind is one of the classes above, or
+// nil when the message is not recognised.
+type YtDlpError struct {
+	Kind    error
+	Message string // the ERROR line printed by yt-dlp
+	Err     error  // how the process ended
+}
+
+func (e *YtDlpError) Error() string {
+	message := e.Message
+	if message == "" {
+		message = e.Err.Error()
+	}
+	if e.Kind == nil {
+		return message
+	}
+	return e.Kind.Error() + ": " + message
+}
+
+// Unwrap lets errors.Is match both the class and the exit error
+func (e *YtDlpError) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// ClassifyError turns a yt-dlp failure and its stderr into a *YtDlpError
+func ClassifyError(err error, stderr []byte) error {
+	if err == nil {
+		return nil
+	}
+
+	var exitErr *exec.ExitError
+	if len(stderr) == 0 && errors.As(err, &exitErr) {
+		stderr = exitErr.Stderr
+	}
+
+	// Classify by the ERROR lines when there are any, warnings about
+	// retried requests are not the reason of the failure
+	lines := errorLines(stderr)
+	text := strings.Join(lines, "\n")
+	if text == "" {
+		text = string(stderr)
+	}
+
+	result := &YtDlpError{Err: err}
+	if len(lines) > 0 {
+		result.Message = lines[len(lines)-1]
+	}
+	for _, pattern := range errorPatterns {
+		if pattern.re.MatchString(text) {
+			result.Kind = pattern.kind
+			break
+		}
+	}
+	return result
+}
+
+// ErrorKind returns the class name of err, e.g. "private", or "" when
+// it is not a classified yt-dlp error
+func ErrorKind(err error) string {
+	var kind *kindError
+	if errors.As(err, &kind) {
+		return kind.kind
+	}
+	return ""
+}
+
+// errorHints tell the user what can be done about a class of failure
+var errorHints = map[error]string{
+	ErrUnavailable:        "The video was removed or the URL is wrong",
+	ErrPrivate:            "Only the owner of a private video can give access to it",
+	ErrMembersOnly:        "The video is for channel members only",
+	ErrAgeRestricted:      "Age-restricted videos need a signed-in account",
+	ErrGeoBlocked:         "The video is blocked in your country, a VPN may help",
+	ErrRateLimited:        "YouTube is limiting requests, wait a while before trying again",
+	ErrFormatNotAvailable: "Choose another quality",
+	ErrNetwork:            "Check the internet connection and try again",
+	ErrFFmpegMissing:      "Put ffmpeg.exe and ffprobe.exe into the bin folder (run download_deps.bat)",
+	ErrPostProcessing:     "The file is downloaded, only the steps after the download failed",
+}
+
+// ErrorHint returns advice for a classified yt-dlp error, or ""
+func ErrorHint(err error) string {
+	for kind, hint := range errorHints {
+		if errors.Is(err, kind) {
+			return hint
+		}
+	}
+	return ""
+}
+
+// errorLines returns the ERROR lines of yt-dlp output without the prefix
+func errorLines(stderr []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(string(stderr), "\r", "\n"), "\n") {
+		if message, ok := strings.CutPrefix(strings.TrimSpace(line), "ERROR: "); ok {
+			lines = append(lines, message)
+		}
+	}
+	return lines
+}
+
+// tailBuffer keeps the last bytes written to it
+type tailBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf.Write(p)
+	if extra := t.buf.Len() - t.limit; extra > 0 {
+		t.buf.Next(extra)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		kind   error
+	}{
+		{"private", "ERROR: [youtube] dQw4w9WgXcQ: Private video. Sign in if you've been granted access to this video", ErrPrivate},
+		{"members-only", "ERROR: [youtube] dQw4w9WgXcQ: Join this channel to get access to members-only content like this video, and other exclusive perks.", ErrMembersOnly},
+		{"members-only, available to members", "ERROR: [youtube] dQw4w9WgXcQ: This video is available to this channel's members on level: Tier 1", ErrMembersOnly},
+		{"age-restricted", "ERROR: [youtube] dQw4w9WgXcQ: Sign in to confirm your age. This video may be inappropriate for some users.", ErrAgeRestricted},
+		{"geo-blocked", "ERROR: [youtube] dQw4w9WgXcQ: The uploader has not made this video available in your country", ErrGeoBlocked},
+		{"geo-restricted", "ERROR: [youtube] dQw4w9WgXcQ: Video unavailable. This video is not available from your location due to geo-restriction", ErrGeoBlocked},
+		{"rate-limited", "ERROR: [youtube] dQw4w9WgXcQ: Unable to download API page: HTTP Error 429: Too Many Requests", ErrRateLimited},
+		{"format", "ERROR: [youtube] dQw4w9WgXcQ: Requested format is not available. Use --list-formats for a list of available formats", ErrFormatNotAvailable},
+		{"ffmpeg", "ERROR: Postprocessing: ffmpeg not found. Please install or provide the path using --ffmpeg-location", ErrFFmpegMissing},
+		{"removed", "ERROR: [youtube] dQw4w9WgXcQ: Video unavailable. This video has been removed by the uploader", ErrUnavailable},
+		{"terminated", "ERROR: [youtube] dQw4w9WgXcQ: Video unavailable. This video is no longer available because the YouTube account associated with this video has been terminated.", ErrUnavailable},
+		{"bad url", "ERROR: [generic] Unsupported URL: https://example.com/", ErrUnavailable},
+		{"incomplete id", "ERROR: [youtube:truncated_id] dQw4w9: Incomplete YouTube ID dQw4w9. URL https://www.youtube.com/watch?v=dQw4w9 looks truncated.", ErrUnavailable},
+		// 5xx is a server hiccup, not a missing video
+		{"server error", "ERROR: [youtube] dQw4w9WgXcQ: Unable to download webpage: HTTP Error 503: Service Unavailable (caused by <HTTPError 503: 'Service Unavailable'>)", ErrNetwork},
+		{"connection reset", "ERROR: unable to download video data: <urlopen error [Errno 104] Connection reset by peer>", ErrNetwork},
+		{"timeout", "ERROR: [download] Got error: The read operation timed out", ErrNetwork},
+		{"dns", "ERROR: [youtube] dQw4w9WgXcQ: Unable to download webpage: <urlopen error [Errno 11001] getaddrinfo failed>", ErrNetwork},
+		{"unknown", "ERROR: something yt-dlp has never said before", nil},
+		{"no error line", "Traceback (most recent call last):\n  Video unavailable", ErrUnavailable},
+	}
+
+	exitErr := errors.New("exit status 1")
+	for _, tt := range tests {
+		err := ClassifyError(exitErr, []byte(tt.stderr))
+
+		var ytErr *YtDlpError
+		if !errors.As(err, &ytErr) {
+			t.Errorf("%s: %T is not a *YtDlpError", tt.name, err)
+			continue
+		}
+		if ytErr.Kind != tt.kind {
+			t.Errorf("%s: kind = %v, want %v", tt.name, ytErr.Kind, tt.kind)
+		}
+		if tt.kind != nil && !errors.Is(err, tt.kind) {
+			t.Errorf("%s: errors.Is(err, %v) = false", tt.name, tt.kind)
+		}
+		if !errors.Is(err, exitErr) {
+			t.Errorf("%s: the exit error is not wrapped", tt.name)
+		}
+	}
+}
+
+func TestClassifyErrorUsesLastErrorLine(t *testing.T) {
+	// Retried requests print warnings first; only ERROR lines count and the
+	// message is the last of them
+	stderr := "WARNING: [youtube] Unable to download webpage: HTTP Error 503\r\n" +
+		"[download] Retrying (1/3)...\n" +
+		"ERROR: [youtube] abc: Private video\n"
+	err := ClassifyError(errors.New("exit status 1"), []byte(stderr))
+
+	if !errors.Is(err, ErrPrivate) || errors.Is(err, ErrNetwork) {
+		t.Errorf("err = %v, want private and not network", err)
+	}
+	var ytErr *YtDlpError
+	if errors.As(err, &ytErr) && ytErr.Message != "[youtube] abc: Private video" {
+		t.Errorf("Message = %q", ytErr.Message)
+	}
+	if got, want := err.Error(), "private video: [youtube] abc: Private video"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestClassifyErrorExitErrorStderr(t *testing.T) {
+	// cmd.Output keeps stderr in the *exec.ExitError
+	exitErr := &exec.ExitError{Stderr: []byte("ERROR: [youtube] abc: Video unavailable\n")}
+	err := ClassifyError(exitErr, nil)
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("err = %v, want unavailable from ExitError.Stderr", err)
+	}
+
+	var target *exec.ExitError
+	if !errors.As(err, &target) || target != exitErr {
+		t.Error("errors.As does not reach the *exec.ExitError")
+	}
+}
+
+func TestClassifyErrorNil(t *testing.T) {
+	if err := ClassifyError(nil, []byte("ERROR: Private video")); err != nil {
+		t.Errorf("ClassifyError(nil) = %v", err)
+	}
+}
+
+func TestErrorKindAndHint(t *testing.T) {
+	err := fmt.Errorf("video download error: %w",
+		ClassifyError(errors.New("exit status 1"), []byte("ERROR: HTTP Error 429: Too Many Requests")))
+	if got := ErrorKind(err); got != "rate-limited" {
+		t.Errorf("ErrorKind = %q, want rate-limited", got)
+	}
+	if ErrorHint(err) == "" {
+		t.Error("ErrorHint is empty for a rate-limited error")
+	}
+
+	plain := errors.New("disk full")
+	if ErrorKind(plain) != "" || ErrorHint(plain) != "" {
+		t.Error("unclassified errors must have no kind and no hint")
+	}
+}
+
+func TestPostProcessingError(t *testing.T) {
+	if err := PostProcessingError(nil, nil); err != nil {
+		t.Errorf("PostProcessingError(nil, nil) = %v", err)
+	}
+
+	thumbnail := fmt.Errorf("thumbnail error: %w",
+		ClassifyError(errors.New("exit status 1"), []byte("ERROR: unable to download webpage: timed out")))
+	chapters := errors.New("chapters error: ffmpeg failed")
+	err := PostProcessingError(thumbnail, nil, chapters)
+
+	if !errors.Is(err, ErrPostProcessing) || !errors.Is(err, ErrNetwork) {
+		t.Errorf("err = %v, want both post-processing and the step's network error", err)
+	}
+	if got := ErrorKind(err); got != "post-processing" {
+		t.Errorf("ErrorKind = %q, want post-processing", got)
+	}
+	want := "post-processing failed: thumbnail error: network error: unable to download webpage: timed out\nchapters error: ffmpeg failed"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
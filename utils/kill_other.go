@@ -0,0 +1,20 @@
+//go:build !windows
+
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// prepareCommand starts the process in its own group, so its children can
+// be killed with it
+func prepareCommand(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessTree kills a process together with its children
+func killProcessTree(process *os.Process) error {
+	return syscall.Kill(-process.Pid, syscall.SIGKILL)
+}
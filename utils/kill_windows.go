@@ -0,0 +1,21 @@
+//go:build windows
+
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// prepareCommand needs nothing on Windows: taskkill finds the children
+func prepareCommand(cmd *exec.Cmd) {}
+
+// killProcessTree kills a process together with its children
+func killProcessTree(process *os.Process) error {
+	kill := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(process.Pid))
+	if err := kill.Run(); err != nil {
+		return process.Kill()
+	}
+	return nil
+}
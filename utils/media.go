@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProbeDuration reads a media file's duration with ffprobe
+func ProbeDuration(ctx context.Context, path string) (time.Duration, error) {
+	cmd := exec.CommandContext(ctx, GetFFprobeBinary(),
+		"-v", "quiet",
+		"-show_entries", "format=duration",
+		"-of", "csv=p=0",
+		path,
+	)
+	output, err := CommandOutputOf(cmd)
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe error: %v", err)
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe duration parse error: %v", err)
+	}
+	return time.Duration(value * float64(time.Second)), nil
+}
+
+// ContainsString reports whether list contains value
+func ContainsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
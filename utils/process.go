@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"yt_downloader/history"
+)
+
+// CommandOutput and CommandErrors receive yt-dlp's console output. The
+// server swaps them to follow the progress of a job.
+var (
+	CommandOutput io.Writer = os.Stdout
+	CommandErrors io.Writer = os.Stderr
+)
+
+// KeepPartialFiles leaves the .part and fragment files of a cancelled
+// download, so running it again continues where it stopped. Set
+// KEEP_PARTIAL_FILES=1 or use "serve -keep-partial" to enable.
+var KeepPartialFiles = os.Getenv("KEEP_PARTIAL_FILES") == "1"
+
+// ErrPaused is the cancel cause of a paused job. Its partial files are
+// always kept, it will be resumed.
+var ErrPaused = errors.New("paused")
+
+// RunCommand runs cmd with its output going to CommandOutput and
+// CommandErrors. cmd should come from exec.CommandContext: when the
+// context is cancelled the whole process tree is killed, as yt-dlp.exe
+// runs Python as a child process that would be orphaned otherwise.
+// A failure is returned as a *YtDlpError classified from stderr.
+func RunCommand(cmd *exec.Cmd) error {
+	stderr := &tailBuffer{limit: 16 * 1024}
+	cmd.Stdout = CommandOutput
+	cmd.Stderr = io.MultiWriter(CommandErrors, stderr)
+	prepareCancel(cmd)
+
+	return ClassifyError(cmd.Run(), stderr.buf.Bytes())
+}
+
+// CommandOutputOf runs cmd like exec.Cmd.Output, killing its process tree
+// when the context of cmd is cancelled
+func CommandOutputOf(cmd *exec.Cmd) ([]byte, error) {
+	prepareCancel(cmd)
+	return cmd.Output()
+}
+
+// CombinedOutputOf runs cmd like exec.Cmd.CombinedOutput, killing its
+// process tree when the context of cmd is cancelled
+func CombinedOutputOf(cmd *exec.Cmd) ([]byte, error) {
+	prepareCancel(cmd)
+	return cmd.CombinedOutput()
+}
+
+// prepareCancel makes cancelling the context of cmd, which must come from
+// exec.CommandContext, kill the whole process tree
+func prepareCancel(cmd *exec.Cmd) {
+	prepareCommand(cmd)
+	cmd.Cancel = func() error {
+		return killProcessTree(cmd.Process)
+	}
+	// Don't hang on output pipes still held by a killed child
+	cmd.WaitDelay = 5 * time.Second
+}
+
+// Interrupted reports whether ctx was cancelled during a download of
+// filename into folder. A cancelled download has its partial files removed,
+// unless KeepPartialFiles is set, and is recorded in history; a paused one
+// keeps its files for resuming.
+func Interrupted(ctx context.Context, url, folder, filename string) bool {
+	if ctx.Err() == nil {
+		return false
+	}
+
+	paused := errors.Is(context.Cause(ctx), ErrPaused)
+	if paused || KeepPartialFiles {
+		fmt.Println("\n⏸ Download stopped, partial files kept for resuming:", filename)
+	} else {
+		removed := RemovePartialFiles(folder, filename)
+		fmt.Printf("\n⏹ Download cancelled: %s (removed %d partial file(s))\n", filename, removed)
+	}
+
+	if !paused {
+		downloadTime := time.Now().Format("2006-01-02 15:04:05")
+		history.SaveToHistoryWithDetails(url, filename, downloadTime, map[string]string{
+			"status": "cancelled",
+		})
+	}
+	return true
+}
+
+// partialStream matches a separately downloaded stream waiting to be
+// merged, e.g. "f137.mp4"
+var partialStream = regexp.MustCompile(`^f\d+[\w-]*\.\w+$`)
+
+// RemovePartialFiles deletes what yt-dlp leaves of an unfinished download
+// of filename: .part and .ytdl files, fragments, unmerged streams and
+// merger temp files. Returns the number of removed files.
+func RemovePartialFiles(folder, filename string) int {
+	if folder == "" {
+		folder = "."
+	}
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return 0
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !isPartialFile(entry.Name(), filename) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(folder, entry.Name())); err != nil {
+			fmt.Println("⚠ Failed to remove partial file:", err)
+			continue
+		}
+		removed++
+	}
+	return removed
+}
+
+func isPartialFile(name, filename string) bool {
+	rest, ok := strings.CutPrefix(name, filename+".")
+	if !ok {
+		return false
+	}
+	return strings.HasSuffix(rest, ".part") ||
+		strings.HasSuffix(rest, ".ytdl") ||
+		strings.Contains(rest, ".part-Frag") ||
+		strings.HasPrefix(rest, "temp.") ||
+		partialStream.MatchString(rest)
+}
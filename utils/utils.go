@@ -2,12 +2,14 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
 	"math"
 	"net/http"
+	neturl "net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -24,28 +26,52 @@ import (
 
 // =================== YouTube Downloader ===================
 
-// getYTDLPBinary returns the path to yt-dlp binary based on the OS
-func getYTDLPBinary() string {
+// GetYTDLPBinary returns the path to the yt-dlp binary in bin/ for this OS
+func GetYTDLPBinary() string {
 	if runtime.GOOS == "windows" {
 		return filepath.Join("bin", "yt-dlp.exe")
 	}
 	return filepath.Join("bin", "yt-dlp")
 }
 
+// GetFFmpegBinary returns the path to ffmpeg: bin/ first, then PATH
+func GetFFmpegBinary() string {
+	return findToolBinary("ffmpeg")
+}
+
+// GetFFprobeBinary returns the path to ffprobe: bin/ first, then PATH
+func GetFFprobeBinary() string {
+	return findToolBinary("ffprobe")
+}
+
+// findToolBinary looks for a helper tool in bin/, then in PATH
+func findToolBinary(name string) string {
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	local := filepath.Join("bin", name)
+	if _, err := os.Stat(local); err == nil {
+		return local
+	}
+	if path, err := exec.LookPath(name); err == nil {
+		return path
+	}
+	return local
+}
+
 // GetVideoTitle extracts the video title using yt-dlp
-func GetVideoTitle(url string) string {
-	ytPath := getYTDLPBinary()
+func GetVideoTitle(ctx context.Context, url string) (string, error) {
+	ytPath := GetYTDLPBinary()
 
 	// Ensure proper encoding flags
-	cmd := exec.Command(ytPath, "--quiet", "--get-title", "--encoding", "utf-8", url)
+	cmd := exec.CommandContext(ctx, ytPath, "--quiet", "--get-title", "--encoding", "utf-8", url)
 
 	// Set Python encoding for Windows
 	cmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8")
 
-	output, err := cmd.Output()
+	output, err := CommandOutputOf(cmd)
 	if err != nil {
-		fmt.Println("⚠ Failed to get title:", err)
-		return ""
+		return "", ClassifyError(err, nil)
 	}
 
 	title := strings.TrimSpace(string(output))
@@ -54,7 +80,7 @@ func GetVideoTitle(url string) string {
 	fmt.Printf("🔍 Title from yt-dlp: '%s'\n", title)
 	fmt.Printf("📏 Length: %d bytes, UTF-8 valid: %t\n", len(title), utf8.ValidString(title))
 
-	return title
+	return title, nil
 }
 
 // =================== File utilities ===================
@@ -165,6 +191,45 @@ func IsValidURL(url string) bool {
 		strings.HasPrefix(url, "https://")
 }
 
+// videoIDPattern matches the 11-character YouTube video ID
+var videoIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+
+// ExtractVideoID returns the YouTube video ID from a URL, or "" if none
+func ExtractVideoID(rawURL string) string {
+	parsed, err := neturl.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ""
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(parsed.Host), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	path := strings.Trim(parsed.Path, "/")
+
+	var id string
+	switch {
+	case host == "youtu.be":
+		id = strings.SplitN(path, "/", 2)[0]
+	case strings.HasSuffix(host, "youtube.com") || strings.HasSuffix(host, "youtube-nocookie.com"):
+		if v := parsed.Query().Get("v"); v != "" {
+			id = v
+			break
+		}
+		// /shorts/ID, /embed/ID, /live/ID, /v/ID
+		parts := strings.Split(path, "/")
+		if len(parts) >= 2 {
+			switch parts[0] {
+			case "shorts", "embed", "live", "v":
+				id = parts[1]
+			}
+		}
+	}
+
+	if !videoIDPattern.MatchString(id) {
+		return ""
+	}
+	return id
+}
+
 // =================== Audio player ===================
 
 // intBufferToBytes converts *audio.IntBuffer to []byte (16-bit LE)
@@ -259,7 +324,7 @@ func playTone(frequencyHz int, durationMs int) error {
 
 // UpdateYtDlp updates yt-dlp binary in bin
 func UpdateYtDlp() {
-	ytPath := getYTDLPBinary()
+	ytPath := GetYTDLPBinary()
 
 	cmd := exec.Command(ytPath, "-U")
 	cmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8")
@@ -275,7 +340,7 @@ func UpdateYtDlp() {
 
 // CheckUpdateYtDlp checks for new yt-dlp version
 func CheckUpdateYtDlp() {
-	ytPath := getYTDLPBinary()
+	ytPath := GetYTDLPBinary()
 
 	// Check local binary exists
 	if _, err := os.Stat(ytPath); os.IsNotExist(err) {
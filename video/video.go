@@ -1,13 +1,19 @@
 package video
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"yt_downloader/chapters"
+	"yt_downloader/mediatags"
+	"yt_downloader/sponsorblock"
+	"yt_downloader/subformat"
 	"yt_downloader/subtitles"
+	"yt_downloader/thumbnail"
 	"yt_downloader/utils"
 )
 
@@ -36,6 +42,18 @@ var VideoQualities = []VideoQuality{
 // Selected quality (default 720p MP4)
 var SelectedVideoQuality = VideoQualities[0]
 
+// Thumbnail controls saving and embedding the video thumbnail
+var Thumbnail thumbnail.Options
+
+// Chapters controls embedding and exporting chapter markers
+var Chapters chapters.Options
+
+// SponsorBlock controls cutting or marking sponsor segments
+var SponsorBlock sponsorblock.Options
+
+// Tags controls embedding title, channel, date and other metadata tags
+var Tags mediatags.Options
+
 // PromptVideoQuality lets user choose video quality
 func PromptVideoQuality() {
 	fmt.Println("Select video quality:")
@@ -73,47 +91,87 @@ func PromptVideoQuality() {
 	fmt.Printf("✅ Selected quality: %s\n", SelectedVideoQuality.Description)
 }
 
+// FindVideoQuality looks up a quality by resolution ("1080p", "best") and
+// optional container ("mp4", "webm", "any"); without a container the first
+// match wins
+func FindVideoQuality(resolution, format string) (VideoQuality, bool) {
+	for _, quality := range VideoQualities {
+		if !strings.EqualFold(quality.Resolution, resolution) {
+			continue
+		}
+		if format == "" || strings.EqualFold(quality.Format, format) {
+			return quality, true
+		}
+	}
+	return VideoQuality{}, false
+}
+
 // GetVideoTitle grabs a safe video title from URL
-func GetVideoTitle(url string) string {
-	title := utils.GetVideoTitle(url)
+func GetVideoTitle(ctx context.Context, url string) (string, error) {
+	title, err := utils.GetVideoTitle(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("failed to get title: %w", err)
+	}
 	if title == "" {
 		title = utils.GenerateFallbackTitle()
 	}
-	return utils.SanitizeFileName(title)
+	return utils.SanitizeFileName(title), nil
 }
 
 // DownloadVideo downloads a video with default subtitle options
-func DownloadVideo(url string, filename string, folder string) {
-	DownloadVideoWithOptions(url, filename, folder, subtitles.DefaultSubtitleOptions)
+func DownloadVideo(ctx context.Context, url string, filename string, folder string) error {
+	return DownloadVideoWithOptions(ctx, url, filename, folder, subtitles.DefaultSubtitleOptions)
 }
 
 // DownloadVideoWithSubtitles downloads a video with subtitle options
-func DownloadVideoWithSubtitles(url string, filename string, folder string, subOptions subtitles.SubtitleOptions) {
-	DownloadVideoWithOptions(url, filename, folder, subOptions)
+func DownloadVideoWithSubtitles(ctx context.Context, url string, filename string, folder string, subOptions subtitles.SubtitleOptions) error {
+	return DownloadVideoWithOptions(ctx, url, filename, folder, subOptions)
 }
 
-// DownloadVideoWithOptions downloads with fully specified options
-func DownloadVideoWithOptions(url string, filename string, folder string, subOptions subtitles.SubtitleOptions) {
+// DownloadVideoWithOptions downloads with fully specified options. When
+// ctx is cancelled yt-dlp is stopped and the partial files are handled
+// by utils.Interrupted. Failed post-processing steps are returned as an
+// error matching utils.ErrPostProcessing; the video is there then.
+func DownloadVideoWithOptions(ctx context.Context, url string, filename string, folder string, subOptions subtitles.SubtitleOptions) error {
+	return DownloadVideoWithQuality(ctx, url, filename, folder, SelectedVideoQuality, subOptions)
+}
+
+// DownloadVideoWithQuality is DownloadVideoWithOptions in the given
+// quality instead of SelectedVideoQuality
+func DownloadVideoWithQuality(ctx context.Context, url string, filename string, folder string, quality VideoQuality, subOptions subtitles.SubtitleOptions) error {
 	// If subtitles requested, use subtitle pipeline
 	if subOptions.DownloadSubtitles {
-		err := subtitles.DownloadWithSubtitles(url, filename, folder, SelectedVideoQuality.YtDlpFormat, subOptions)
-		if err != nil {
-			fmt.Printf("⚠ Error: %v\n", err)
+		// Segments are cut before the subtitles are processed, which then
+		// follow the cuts
+		var cut *segmentCut
+		err := subtitles.DownloadWithSubtitles(ctx, url, filename, folder, quality.YtDlpFormat, subOptions,
+			func(videoPath string) []subformat.TimeRange {
+				cut = cutSegments(ctx, url, videoPath)
+				return cut.segments.KeptRanges()
+			})
+		if err != nil && !errors.Is(err, utils.ErrPostProcessing) {
+			return err
+		}
+		if postErr := processPostDownload(ctx, url, filename, folder, cut); postErr != nil {
+			if !errors.Is(postErr, utils.ErrPostProcessing) {
+				return postErr
+			}
+			return errors.Join(err, postErr)
 		}
-		return
+		return err
 	}
 
 	// Regular download without subtitles
-	ytPath := filepath.Join("bin", "yt-dlp.exe")
+	ytPath := utils.GetYTDLPBinary()
 	outPath := filepath.Join(folder, filename+".%(ext)s")
 
 	fmt.Printf("🎬 Downloading video: %s\n", filename)
 	fmt.Printf("📁 Saving to: %s\n", folder)
-	fmt.Printf("🎯 Quality: %s\n", SelectedVideoQuality.Description)
+	fmt.Printf("🎯 Quality: %s\n", quality.Description)
 
 	// yt-dlp arguments
 	args := []string{
-		"-f", SelectedVideoQuality.YtDlpFormat, // quality format
+		"-f", quality.YtDlpFormat, // quality format
 		"-o", outPath, // output path
 		"--no-warnings",   // warnings off
 		"--console-title", // show process in title
@@ -127,73 +185,85 @@ func DownloadVideoWithOptions(url string, filename string, folder string, subOpt
 		"--fragment-retries", "3", // repeate fragments 3 times
 	)
 
-	cmd := exec.Command(ytPath, args...)
+	cmd := exec.CommandContext(ctx, ytPath, args...)
 
 	// Set encoding for proper console output
 	cmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8")
 
-	// Forward output
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
 	fmt.Println("🚀 Starting download...")
 
-	if err := cmd.Run(); err != nil {
-		fmt.Printf("⚠ Video download error: %v\n", err)
-		return
+	// Output is forwarded to the console
+	if err := utils.RunCommand(cmd); err != nil {
+		if utils.Interrupted(ctx, url, folder, filename) {
+			return context.Cause(ctx)
+		}
+		return fmt.Errorf("video download error: %w", err)
 	}
 
 	fmt.Printf("✅ Video downloaded successfully: %s\n", filename)
+	if err := processPostDownload(ctx, url, filename, folder, nil); err != nil {
+		return err
+	}
 	utils.PlayBeepShort() // short completion beep
+	return nil
 }
 
-// ProcessVideoBatchFile processes a file with video URLs
-func ProcessVideoBatchFile(filePath string) {
-	file, err := os.Open(filePath)
+// segmentCut is the outcome of the SponsorBlock step
+type segmentCut struct {
+	segments *sponsorblock.Result
+	err      error
+}
+
+// cutSegments runs the SponsorBlock step on a downloaded file
+func cutSegments(ctx context.Context, url, videoPath string) *segmentCut {
+	segments, err := sponsorblock.Process(ctx, url, videoPath, SponsorBlock)
 	if err != nil {
-		fmt.Printf("⚠ can't open file: %s\n", filePath)
-		return
+		err = fmt.Errorf("SponsorBlock error: %w", err)
 	}
-	defer file.Close()
-
-	var urls []string
-	scanner := bufio.NewScanner(file)
+	return &segmentCut{segments: segments, err: err}
+}
 
-	// Read URLs from file
-	for scanner.Scan() {
-		url := strings.TrimSpace(scanner.Text())
-		if url == "" || strings.HasPrefix(url, "#") {
-			continue // skip empty lines and comments
-		}
-		urls = append(urls, url)
+// processPostDownload applies SponsorBlock, thumbnail, chapter and tag
+// options to a finished download. Segments are cut first so chapters follow
+// the cuts; cut is the SponsorBlock step when it already ran, or nil.
+// Each step finishes its file; a cancel skips the remaining ones. A failed
+// step doesn't stop the next ones, the step errors are returned together
+// as a utils.PostProcessingError.
+func processPostDownload(ctx context.Context, url, filename, folder string, cut *segmentCut) error {
+	if !SponsorBlock.Enabled() && !Thumbnail.Enabled() && !Chapters.Enabled() && !Tags.Embed {
+		return nil
 	}
 
-	if err := scanner.Err(); err != nil {
-		fmt.Printf("⚠ File read error: %v\n", err)
-		return
+	videoPath, err := subtitles.FindVideoFile(folder, filename)
+	if err != nil {
+		return fmt.Errorf("post-processing error: %w", err)
 	}
 
-	if len(urls) == 0 {
-		fmt.Println("⚠ No valid URLs found in file")
-		return
+	if cut == nil {
+		cut = cutSegments(ctx, url, videoPath)
 	}
-
-	fmt.Printf("📋 Found %d videos to download\n", len(urls))
-	folder, _ := os.Getwd()
-
-	// Process each URL
-	for i, url := range urls {
-		fmt.Printf("\n🎬 Processing %d/%d: %s\n", i+1, len(urls), url)
-
-		fileName := GetVideoTitle(url)
-		DownloadVideo(url, fileName, folder)
-
-		// Optional pause between downloads
-		if i < len(urls)-1 {
-			fmt.Println("⏳ Pause 2 seconds before next video...")
-			// time.Sleep(2 * time.Second) // uncomment if pause needs
-		}
+	var errs []error
+	if cut.err != nil {
+		errs = append(errs, cut.err)
 	}
-
-	fmt.Printf("\n🎉 Batch download completed! Processed: %d\n", len(urls))
+	segments := cut.segments
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	if err := thumbnail.Process(ctx, url, videoPath, Thumbnail); err != nil {
+		errs = append(errs, fmt.Errorf("thumbnail error: %w", err))
+	}
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	if err := chapters.Process(ctx, url, videoPath, segments.ChapterOptions(Chapters), segments.EditChapters); err != nil {
+		errs = append(errs, fmt.Errorf("chapters error: %w", err))
+	}
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	if err := mediatags.Process(ctx, url, videoPath, Tags); err != nil {
+		errs = append(errs, fmt.Errorf("metadata tags error: %w", err))
+	}
+	return utils.PostProcessingError(errs...)
 }